@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/internal/service"
+)
+
+func runAudit(args []string) error {
+	if len(args) == 0 {
+		return errors.New("audit: missing subcommand (list, archive, verify, checkpoint)")
+	}
+	sub, args := args[0], args[1:]
+	ctx := cliContext()
+
+	switch sub {
+	case "list":
+		fs, asJSON := newFlagSet("audit list")
+		actor := fs.String("actor", "", "only entries by this user (id, email, +phone or username)")
+		target := fs.String("target", "", "only entries about this user (id, email, +phone or username)")
+		action := fs.String("action", "", "only entries of this action, e.g. user.role_changed")
+		since := fs.Duration("since", 0, "only entries of the last duration, e.g. 24h")
+		limit := fs.Int("limit", 50, "maximum number of entries")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		filter := repository.AuditFilter{Action: *action, Limit: *limit}
+		if *since > 0 {
+			filter.From = time.Now().Add(-*since)
+		}
+		for _, ref := range []struct {
+			value string
+			id    *uint
+		}{{*actor, &filter.ActorID}, {*target, &filter.TargetID}} {
+			if ref.value == "" {
+				continue
+			}
+			if *ref.id, err = userIDOf(ctx, a, ref.value); err != nil {
+				return err
+			}
+		}
+		entries, err := a.AuditService.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+		return printAuditEntries(entries, *asJSON)
+
+	case "archive":
+		fs, asJSON := newFlagSet("audit archive")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		archived, err := a.AuditService.Archive(ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(map[string]interface{}{"archived": archived, "dir": a.Config.Audit.ArchiveDir})
+		}
+		fmt.Printf("🗄️  archived %d audit entries to %s\n", archived, a.Config.Audit.ArchiveDir)
+		return nil
+
+	case "verify":
+		fs, asJSON := newFlagSet("audit verify")
+		full := fs.Bool("full", false, "walk every entry instead of starting at the latest checkpoint")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		result, err := a.AuditService.Verify(ctx, *full)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			if err := printJSON(result); err != nil {
+				return err
+			}
+		} else {
+			printAuditVerification(result)
+		}
+		if result.Break != nil {
+			return fmt.Errorf("audit chain broken at %s", result.Break)
+		}
+		return nil
+
+	case "checkpoint":
+		fs, asJSON := newFlagSet("audit checkpoint")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		cp, err := a.AuditService.Checkpoint(ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(cp)
+		}
+		if cp == nil {
+			fmt.Println("🔏 no entries since the latest checkpoint")
+			return nil
+		}
+		fmt.Printf("🔏 signed checkpoint %d at entry %d\n", cp.ID, cp.EntryID)
+		return nil
+	}
+	return fmt.Errorf("audit: unknown subcommand %q", sub)
+}
+
+// printAuditEntries prints audit entries as JSON or as an aligned table
+func printAuditEntries(entries []models.AuditEntry, asJSON bool) error {
+	if asJSON {
+		return printJSON(entries)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tACTION\tACTOR\tTARGET\tSOURCE\tCHANGED")
+	for _, e := range entries {
+		changed := make([]string, 0, len(e.Changes))
+		for field := range e.Changes {
+			changed = append(changed, field)
+		}
+		sort.Strings(changed)
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", e.ID, e.CreatedAt.Format(time.RFC3339), e.Action,
+			auditUser(e.ActorID), auditUser(e.TargetID), e.Source, strings.Join(changed, ","))
+	}
+	return w.Flush()
+}
+
+// printAuditVerification prints the outcome of an audit chain walk
+func printAuditVerification(r *service.AuditVerification) {
+	if r.AnchorID != 0 {
+		fmt.Printf("anchored at checkpoint %d (entry %d)\n", r.AnchorID, r.AnchorEntryID)
+	} else {
+		fmt.Println("anchored at the start of the chain")
+	}
+	if r.Entries > 0 {
+		fmt.Printf("checked %d entries, %d through %d (%d redacted by purges)\n", r.Entries, r.FromID, r.ThroughID, r.Redacted)
+	} else {
+		fmt.Println("checked 0 entries")
+	}
+	fmt.Printf("%d valid checkpoints", r.Checkpoints)
+	if r.Unverifiable > 0 {
+		fmt.Printf(", %d signed with retired keys", r.Unverifiable)
+	}
+	fmt.Println()
+	if r.Break == nil {
+		fmt.Println("✅ audit chain intact")
+	}
+}
+
+func auditUser(id *uint) string {
+	if id == nil {
+		return "-"
+	}
+	return fmt.Sprint(*id)
+}
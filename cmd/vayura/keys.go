@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/vayura/config"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/fieldcrypt"
+)
+
+const keysUsage = "usage: vayura keys rotate [-file PATH] [-retain N] | rotate-pii [-file PATH] | reencrypt | retire-pii <kid>"
+
+func runKeys(args []string) error {
+	if len(args) == 0 {
+		return errors.New(keysUsage)
+	}
+	switch args[0] {
+	case "rotate":
+		return rotateJWTKeys(args[1:])
+	case "rotate-pii":
+		return rotatePIIKeys(args[1:])
+	case "reencrypt":
+		return reencryptPII(args[1:])
+	case "retire-pii":
+		return retirePIIKey(args[1:])
+	}
+	return errors.New(keysUsage)
+}
+
+func rotateJWTKeys(args []string) error {
+	cfg := config.Load()
+	flags, asJSON := newFlagSet("keys rotate")
+	file := flags.String("file", cfg.JWT.KeysFile, "JWT key set file (defaults to JWT_KEYS_FILE)")
+	retain := flags.Int("retain", 2, "number of previous keys kept for verifying outstanding tokens")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("keys rotate: set JWT_KEYS_FILE or pass -file")
+	}
+
+	ks, err := pkg.LoadKeySet(*file)
+	if errors.Is(err, fs.ErrNotExist) {
+		ks, err = &pkg.KeySet{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	key, err := ks.Rotate(*retain)
+	if err != nil {
+		return err
+	}
+	if err := ks.Save(*file); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(map[string]interface{}{"active": key.ID, "keys": len(ks.Keys), "file": *file})
+	}
+	fmt.Printf("🔑 new active key %s (%d keys in %s); restart servers to pick it up\n", key.ID, len(ks.Keys), *file)
+	return nil
+}
+
+// rotatePIIKeys adds a new active key-encryption key; previous keys stay until retired
+func rotatePIIKeys(args []string) error {
+	cfg := config.Load()
+	flags, asJSON := newFlagSet("keys rotate-pii")
+	file := flags.String("file", cfg.PII.KeysFile, "PII keyring file (defaults to PII_KEYS_FILE)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	kr, err := fieldcrypt.LoadKeyring(*file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("keys rotate-pii: %s not found; it is created on the first start of the server", *file)
+	}
+	if err != nil {
+		return err
+	}
+	key, err := kr.Rotate()
+	if err != nil {
+		return err
+	}
+	if err := kr.Save(*file); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(map[string]interface{}{"active": key.ID, "keys": len(kr.Keys), "file": *file})
+	}
+	fmt.Printf("🔑 new active PII key %s (%d keys in %s); restart servers to pick it up, they re-encrypt stored values in the background\n", key.ID, len(kr.Keys), *file)
+	return nil
+}
+
+// reencryptPII moves every encrypted value to the active key now, instead of waiting for the server
+func reencryptPII(args []string) error {
+	flags, asJSON := newFlagSet("keys reencrypt")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := openApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	ctx := context.Background()
+	rewritten, err := a.ReencryptService.Reencrypt(ctx)
+	if err != nil {
+		return err
+	}
+	usage, err := a.ReencryptService.KeyUsage(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(map[string]interface{}{"reencrypted": rewritten, "usage": usage})
+	}
+	fmt.Printf("🔐 re-encrypted %d rows\n", rewritten)
+	kids := make([]string, 0, len(usage))
+	for kid := range usage {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	for _, kid := range kids {
+		fmt.Printf("  %s: %d values\n", kid, usage[kid])
+	}
+	return nil
+}
+
+// retirePIIKey removes a key-encryption key from the keyring once no stored value uses it
+func retirePIIKey(args []string) error {
+	flags, asJSON := newFlagSet("keys retire-pii")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: vayura keys retire-pii [-json] <kid>")
+	}
+	kid := flags.Arg(0)
+
+	a, err := openApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	usage, err := a.ReencryptService.KeyUsage(context.Background())
+	if err != nil {
+		return err
+	}
+	if n := usage[kid]; n > 0 {
+		return fmt.Errorf("key %s still wraps %d values; run `vayura keys reencrypt` first", kid, n)
+	}
+
+	file := a.Config.PII.KeysFile
+	kr, err := fieldcrypt.LoadKeyring(file)
+	if err != nil {
+		return err
+	}
+	if err := kr.Retire(kid); err != nil {
+		return err
+	}
+	if err := kr.Save(file); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(map[string]interface{}{"retired": kid, "keys": len(kr.Keys), "file": file})
+	}
+	fmt.Printf("🗑️  retired PII key %s (%d keys left in %s)\n", kid, len(kr.Keys), file)
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+
+	"github.com/vayura/config"
+	"github.com/vayura/internal/app"
+	"github.com/vayura/pkg"
+)
+
+const usage = `Usage: vayura <command> [arguments]
+
+Commands:
+  serve [-verify-schema]                      Run the HTTP server
+  migrate up|down|status|create               Manage database migrations
+  user create|list|set-role|reset-password|suspend|delete|history
+                                              Manage user accounts
+  token issue|inspect                         Issue or inspect JWTs
+  keys rotate|rotate-pii|reencrypt|retire-pii Rotate the JWT signing keys or the PII encryption keys
+  audit list|archive|verify|checkpoint        Query, archive or verify the audit log
+  username list|grant|revoke                  Manage grants of reserved usernames
+  seed                                        Create an admin and demo users for development
+
+Most commands accept -json for machine-readable output.
+Run "vayura <command> -h" for command flags.
+`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	commands := map[string]func(args []string) error{
+		"serve":    runServe,
+		"migrate":  runMigrate,
+		"user":     runUser,
+		"token":    runToken,
+		"keys":     runKeys,
+		"audit":    runAudit,
+		"username": runUsername,
+		"seed":     runSeed,
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		fmt.Print(usage)
+		return
+	}
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
+	}
+	if err := run(os.Args[2:]); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+}
+
+// openApp loads configuration and builds the application services
+func openApp() (*app.App, error) {
+	return app.New(config.Load())
+}
+
+// cliContext returns the context of a command; the audit log records its changes as made from
+// the CLI by the operating system user
+func cliContext() context.Context {
+	agent := "vayura-cli"
+	if u, err := user.Current(); err == nil {
+		agent += " (" + u.Username + ")"
+	}
+	return pkg.WithActor(context.Background(), pkg.Actor{Source: pkg.SourceCLI, UserAgent: agent})
+}
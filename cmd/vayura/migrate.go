@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/vayura/internal/migrate"
+)
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate: missing subcommand (up, down, status, create)")
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		m, closeApp, err := newMigrator()
+		if err != nil {
+			return err
+		}
+		defer closeApp()
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Printf("✅ applied %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return nil
+
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "number of migrations to revert")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		m, closeApp, err := newMigrator()
+		if err != nil {
+			return err
+		}
+		defer closeApp()
+		reverted, err := m.Down(ctx, *steps)
+		for _, mig := range reverted {
+			fmt.Printf("↩️  reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		return err
+
+	case "status":
+		fs, asJSON := newFlagSet("migrate status")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		m, closeApp, err := newMigrator()
+		if err != nil {
+			return err
+		}
+		defer closeApp()
+		list, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(list)
+		}
+		for _, st := range list {
+			state := "pending"
+			if st.Applied {
+				state = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", st.Version, st.Name, state)
+		}
+		return nil
+
+	case "create":
+		fs := flag.NewFlagSet("migrate create", flag.ContinueOnError)
+		dir := fs.String("dir", "migrations", "root directory holding one migrations directory per dialect")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return errors.New("usage: vayura migrate create [-dir DIR] <name>")
+		}
+		created, err := migrate.Create(*dir, fs.Arg(0))
+		for _, f := range created {
+			fmt.Printf("created %s\n", f)
+		}
+		return err
+	}
+	return fmt.Errorf("migrate: unknown subcommand %q", args[0])
+}
+
+// newMigrator opens the app and returns its migrator along with a func closing the app
+func newMigrator() (*migrate.Migrator, func() error, error) {
+	a, err := openApp()
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := a.Migrator()
+	if err != nil {
+		a.Close()
+		return nil, nil, err
+	}
+	return m, a.Close, nil
+}
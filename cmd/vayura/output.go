@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vayura/internal/models"
+)
+
+// newFlagSet creates a flag set with the shared -json flag
+func newFlagSet(name string) (*flag.FlagSet, *bool) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print machine-readable JSON")
+	return fs, asJSON
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printUsers prints users as JSON or as an aligned table
+func printUsers(users []models.User, asJSON bool) error {
+	if asJSON {
+		return printJSON(users)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\tROLE\tSTATUS")
+	for _, u := range users {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", u.ID, u.Username, u.Email, u.Role, userStatus(u))
+	}
+	return w.Flush()
+}
+
+func printUser(u *models.User, asJSON bool) error {
+	return printUsers([]models.User{*u}, asJSON)
+}
+
+func userStatus(u models.User) string {
+	switch {
+	case u.DeletedAt.Valid:
+		return "deleted"
+	case u.IsSuspended():
+		return "suspended"
+	}
+	return "active"
+}
+
+// printUsernameGrants prints username grants as JSON or as an aligned table
+func printUsernameGrants(grants []models.UsernameGrant, asJSON bool) error {
+	if asJSON {
+		return printJSON(grants)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "USERNAME\tUSER\tGRANTED BY\tGRANTED AT")
+	for _, g := range grants {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", g.Username, g.UserID, auditUser(g.GrantedBy), g.CreatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+// printProfileVersions prints profile versions as JSON or as an aligned table
+func printProfileVersions(versions []models.ProfileVersion, asJSON bool) error {
+	if asJSON {
+		return printJSON(versions)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tVALID FROM\tVALID TO\tUSERNAME\tFULL NAME\tEMAIL\tACTOR\tSOURCE")
+	for _, v := range versions {
+		validTo := "-"
+		if v.ValidTo != nil {
+			validTo = v.ValidTo.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", v.ID, v.CreatedAt.Format(time.RFC3339), validTo,
+			v.Username, v.FullName, v.Email, auditUser(v.ActorID), v.Source)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+)
+
+func runSeed(args []string) error {
+	fs, asJSON := newFlagSet("seed")
+	adminEmail := fs.String("admin-email", "admin@vayura.local", "email of the admin account")
+	adminPassword := fs.String("admin-password", "", "password of the admin account (generated when empty)")
+	demoUsers := fs.Int("users", 5, "number of demo users to create")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *adminPassword == "" {
+		*adminPassword = randomPassword()
+	}
+
+	a, err := openApp()
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	ctx := cliContext()
+
+	reqs := []service.RegisterRequest{{
+		FullName: "Vayura Admin",
+		Username: "vayura_admin",
+		Email:    *adminEmail,
+		Password: *adminPassword,
+		Role:     models.RoleAdmin,
+	}}
+	for i := 1; i <= *demoUsers; i++ {
+		reqs = append(reqs, service.RegisterRequest{
+			FullName: fmt.Sprintf("Demo User %d", i),
+			Username: fmt.Sprintf("demo%d", i),
+			Email:    fmt.Sprintf("demo%d@vayura.local", i),
+			Password: "password123",
+			Role:     models.RoleUser,
+		})
+	}
+
+	created := []models.User{}
+	skipped := 0
+	adminCreated := false
+	for _, req := range reqs {
+		user, err := a.AdminService.CreateUser(ctx, req)
+		if errors.Is(err, pkg.ErrEmailExists) || errors.Is(err, pkg.ErrUsernameExists) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		created = append(created, *user)
+		adminCreated = adminCreated || user.Role == models.RoleAdmin
+	}
+
+	if *asJSON {
+		out := map[string]interface{}{"created": created, "skipped": skipped}
+		if adminCreated {
+			out["admin_password"] = *adminPassword
+		}
+		return printJSON(out)
+	}
+	if err := printUsers(created, false); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d created, %d already existed\n", len(created), skipped)
+	if adminCreated {
+		fmt.Printf("admin %s password: %s\n", *adminEmail, *adminPassword)
+	}
+	fmt.Println("demo users password: password123")
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/vayura/internal/app"
+)
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	verifySchema := fs.Bool("verify-schema", false, "only verify that migrations are applied instead of running them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := openApp()
+	if err != nil {
+		return err
+	}
+	// Serve closes the app on shutdown; this covers a failed startup
+	defer a.Close()
+	return a.Serve(context.Background(), app.ServeOptions{VerifySchema: *verifySchema})
+}
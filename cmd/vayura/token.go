@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vayura/pkg"
+)
+
+func runToken(args []string) error {
+	if len(args) == 0 {
+		return errors.New("token: missing subcommand (issue, inspect)")
+	}
+	sub, args := args[0], args[1:]
+
+	switch sub {
+	case "issue":
+		fs, asJSON := newFlagSet("token issue")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return errors.New("usage: vayura token issue [-json] <id|email|username>")
+		}
+
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		user, err := a.AdminService.FindUser(cliContext(), fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		token, err := a.SessionService.Start(cliContext(), user, "", "vayura-cli")
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(map[string]interface{}{"user_id": user.ID, "token": token})
+		}
+		fmt.Println(token)
+		return nil
+
+	case "inspect":
+		fs, asJSON := newFlagSet("token inspect")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return errors.New("usage: vayura token inspect [-json] <token>")
+		}
+
+		// Load keys and secret so the signature can be checked
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		token, err := pkg.VerifyJWT(fs.Arg(0))
+		out := map[string]interface{}{
+			"valid":  err == nil && token != nil && token.Valid,
+			"header": nil,
+			"claims": nil,
+		}
+		if err != nil {
+			out["error"] = err.Error()
+		}
+		if token != nil {
+			out["header"] = token.Header
+			if claims, cErr := pkg.ExtractClaims(token); cErr == nil {
+				out["claims"] = claims
+			}
+		}
+		if *asJSON {
+			return printJSON(out)
+		}
+		fmt.Printf("valid:  %v\n", out["valid"])
+		if e, ok := out["error"]; ok {
+			fmt.Printf("error:  %v\n", e)
+		}
+		fmt.Printf("header: %v\nclaims: %v\n", out["header"], out["claims"])
+		return nil
+	}
+	return fmt.Errorf("token: unknown subcommand %q", sub)
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/vayura/internal/app"
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/internal/service"
+)
+
+func runUser(args []string) error {
+	if len(args) == 0 {
+		return errors.New("user: missing subcommand (create, list, set-role, reset-password, suspend, delete, history)")
+	}
+	sub, args := args[0], args[1:]
+	ctx := cliContext()
+
+	switch sub {
+	case "create":
+		fs, asJSON := newFlagSet("user create")
+		username := fs.String("username", "", "username (required)")
+		email := fs.String("email", "", "email (required)")
+		fullName := fs.String("full-name", "", "full name (defaults to username)")
+		password := fs.String("password", "", "password (generated when empty)")
+		role := fs.String("role", "user", "role: user or admin")
+		reserved := fs.Bool("reserved", false, "allow a reserved username and grant it to the new user")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *fullName == "" {
+			*fullName = *username
+		}
+		generated := *password == ""
+		if generated {
+			*password = randomPassword()
+		}
+
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		user, err := a.AdminService.CreateUser(ctx, service.RegisterRequest{
+			FullName: *fullName,
+			Username: *username,
+			Email:    *email,
+			Password: *password,
+			Role:     *role,
+
+			GrantReserved: *reserved,
+		})
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			out := map[string]interface{}{"user": user}
+			if generated {
+				out["password"] = *password
+			}
+			return printJSON(out)
+		}
+		if err := printUser(user, false); err != nil {
+			return err
+		}
+		if generated {
+			fmt.Printf("\ngenerated password: %s\n", *password)
+		}
+		return nil
+
+	case "list":
+		fs, asJSON := newFlagSet("user list")
+		role := fs.String("role", "", "filter by role")
+		search := fs.String("search", "", "filter by username, email or name")
+		deleted := fs.Bool("deleted", false, "list soft-deleted users only")
+		limit := fs.Int("limit", 100, "maximum number of users")
+		offset := fs.Int("offset", 0, "number of users to skip")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		users, err := a.AdminService.ListUsers(ctx, repository.ListOptions{
+			Role:        *role,
+			Search:      *search,
+			OnlyDeleted: *deleted,
+			Limit:       *limit,
+			Offset:      *offset,
+		})
+		if err != nil {
+			return err
+		}
+		return printUsers(users, *asJSON)
+
+	case "set-role":
+		fs, asJSON := newFlagSet("user set-role")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 2 {
+			return errors.New("usage: vayura user set-role [-json] <id|email|+phone|username> <role>")
+		}
+
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		target, err := a.AdminService.FindUser(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		user, err := a.AdminService.SetRole(ctx, target.ID, fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		return printUser(user, *asJSON)
+
+	case "reset-password":
+		fs, asJSON := newFlagSet("user reset-password")
+		password := fs.String("password", "", "new password (generated when empty)")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return errors.New("usage: vayura user reset-password [-json] [-password P] <id|email|+phone|username>")
+		}
+		if *password == "" {
+			*password = randomPassword()
+		}
+
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		target, err := a.AdminService.FindUser(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if err := a.AdminService.ResetPassword(ctx, target.ID, *password); err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(map[string]interface{}{"user_id": target.ID, "password": *password})
+		}
+		fmt.Printf("password of %s reset to: %s\n", target.Username, *password)
+		return nil
+
+	case "suspend":
+		fs, asJSON := newFlagSet("user suspend")
+		undo := fs.Bool("undo", false, "lift the suspension")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return errors.New("usage: vayura user suspend [-json] [-undo] <id|email|+phone|username>")
+		}
+
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		target, err := a.AdminService.FindUser(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		user, err := a.AdminService.Suspend(ctx, target.ID, !*undo)
+		if err != nil {
+			return err
+		}
+		return printUser(user, *asJSON)
+
+	case "delete":
+		fs, asJSON := newFlagSet("user delete")
+		hard := fs.Bool("hard", false, "permanently delete instead of soft delete")
+		purge := fs.Bool("purge", false, "permanently delete all soft-deleted users")
+		olderThan := fs.Duration("older-than", 0, "with -purge, only users deleted longer ago than this")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		if *purge {
+			// users purged before a failure stay purged, so they are reported along with the error
+			purged, err := a.AdminService.PurgeDeleted(ctx, time.Now().Add(-*olderThan))
+			if *asJSON {
+				if printErr := printJSON(map[string]int{"purged": purged}); printErr != nil {
+					return printErr
+				}
+			} else {
+				fmt.Printf("purged %d deleted users\n", purged)
+			}
+			return err
+		}
+
+		if fs.NArg() != 1 {
+			return errors.New("usage: vayura user delete [-json] [-hard] <id|email|+phone|username> | -purge [-older-than D]")
+		}
+		target, err := a.AdminService.FindUser(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		if err := a.AdminService.DeleteUser(ctx, target.ID, *hard); err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(map[string]interface{}{"deleted": target.ID, "hard": *hard})
+		}
+		fmt.Printf("deleted user %s\n", target.Username)
+		return nil
+
+	case "history":
+		fs, asJSON := newFlagSet("user history")
+		asOf := fs.String("as-of", "", "show the profile as it was at this RFC 3339 time instead")
+		limit := fs.Int("limit", 50, "maximum number of versions")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return errors.New("usage: vayura user history [-json] [-as-of TIME] [-limit N] <id|email|+phone|username>")
+		}
+
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		userID, err := userIDOf(ctx, a, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		var versions []models.ProfileVersion
+		if *asOf != "" {
+			t, err := time.Parse(time.RFC3339, *asOf)
+			if err != nil {
+				return fmt.Errorf("user history: -as-of: %w", err)
+			}
+			version, err := a.HistoryService.AsOf(ctx, userID, t)
+			if err != nil {
+				return err
+			}
+			versions = append(versions, *version)
+		} else if versions, err = a.HistoryService.List(ctx, userID, 0, *limit); err != nil {
+			return err
+		}
+		return printProfileVersions(versions, *asJSON)
+	}
+	return fmt.Errorf("user: unknown subcommand %q", sub)
+}
+
+// userIDOf resolves a user reference; numeric IDs are used as is, since purged users cannot be
+// looked up anymore
+func userIDOf(ctx context.Context, a *app.App, ref string) (uint, error) {
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		return uint(id), nil
+	}
+	user, err := a.AdminService.FindUser(ctx, ref)
+	if err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+func randomPassword() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+func runUsername(args []string) error {
+	if len(args) == 0 {
+		return errors.New("username: missing subcommand (list, grant, revoke)")
+	}
+	sub, args := args[0], args[1:]
+	ctx := cliContext()
+
+	switch sub {
+	case "list":
+		fs, asJSON := newFlagSet("username list")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		grants, err := a.UsernamePolicy.ListGrants(ctx)
+		if err != nil {
+			return err
+		}
+		return printUsernameGrants(grants, *asJSON)
+
+	case "grant":
+		fs, asJSON := newFlagSet("username grant")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 2 {
+			return errors.New("usage: vayura username grant [-json] <username> <id|email|+phone|username>")
+		}
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		userID, err := userIDOf(ctx, a, fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		grant, err := a.UsernamePolicy.Grant(ctx, fs.Arg(0), userID)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(grant)
+		}
+		fmt.Printf("granted %s to user %d\n", grant.Username, grant.UserID)
+		return nil
+
+	case "revoke":
+		fs, asJSON := newFlagSet("username revoke")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return errors.New("usage: vayura username revoke [-json] <username>")
+		}
+		a, err := openApp()
+		if err != nil {
+			return err
+		}
+		defer a.Close()
+		if err := a.UsernamePolicy.Revoke(ctx, fs.Arg(0)); err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(map[string]interface{}{"revoked": fs.Arg(0)})
+		}
+		fmt.Printf("revoked the grant of %s\n", fs.Arg(0))
+		return nil
+	}
+	return fmt.Errorf("username: unknown subcommand %q", sub)
+}
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -24,9 +27,23 @@ type Config struct {
 	JWT      JWTConfig
 	Server   ServerConfig
 	Storage  StorageConfig
+	Health   HealthConfig
+	Mail     MailConfig
+	SMS      SMSConfig
+	Account  AccountConfig
+	PII      PIIConfig
+	Audit    AuditConfig
 }
 
+// Supported database drivers
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 type DatabaseConfig struct {
+	Driver   string // postgres or sqlite
+	Path     string // sqlite database file, ":memory:" for an in-memory database
 	Host     string
 	User     string
 	Password string
@@ -37,14 +54,91 @@ type DatabaseConfig struct {
 type JWTConfig struct {
 	Secret    string
 	ExpiresIn time.Duration
+	KeysFile  string // optional rotating key set, see `vayura keys rotate`
 }
 
 type ServerConfig struct {
-	Port string
+	Port              string
+	ReadHeaderTimeout time.Duration
+	DrainDelay        time.Duration // time between failing readiness and closing the listener
+	ShutdownTimeout   time.Duration // deadline for draining requests, workers and closers
 }
 
 type StorageConfig struct {
 	UploadDir string
+	ExportDir string // personal data export archives
+}
+
+type HealthConfig struct {
+	CacheTTL     time.Duration // how long check results are reused
+	CheckTimeout time.Duration // default deadline of a single check
+}
+
+// Supported mail drivers
+const (
+	MailDriverConsole = "console"
+	MailDriverSMTP    = "smtp"
+)
+
+type MailConfig struct {
+	Driver       string // console (log, and write .eml files to Dir if set) or smtp
+	From         string
+	Dir          string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// Supported SMS drivers
+const (
+	SMSDriverConsole = "console"
+	SMSDriverHTTP    = "http"
+)
+
+type SMSConfig struct {
+	Driver       string // console (log, and append to File if set) or http
+	File         string
+	Sender       string        // sender ID passed to the gateway
+	GatewayURL   string        // endpoint receiving POST {"to", "from", "message"}
+	GatewayToken string        // sent as a Bearer token when set
+	Timeout      time.Duration // deadline of a single gateway request
+}
+
+type AccountConfig struct {
+	PublicURL           string        // base URL of the web app, used in links sent to users
+	EmailChangeTTL      time.Duration // how long an email change confirmation link is valid
+	EmailChangeCooldown time.Duration // minimum time between two email change requests
+	PhoneRegion         string        // region of phone numbers written without a country code
+	PhoneOTPTTL         time.Duration // how long a phone verification code is valid
+	PhoneOTPCooldown    time.Duration // minimum time between two codes sent to a user
+	PhoneOTPMaxAttempts int           // wrong guesses allowed per code
+	SettingsCacheTTL    time.Duration // how long a user's settings are cached; 0 disables the cache
+	SettingsCacheSize   int           // users whose settings are cached at most
+	DeletionGrace       time.Duration // how long a deleted account can be restored before it is purged
+	PurgeInterval       time.Duration // how often the purge worker looks for accounts past their grace period
+	ExportTTL           time.Duration // how long a data export can be downloaded
+	ExportInterval      time.Duration // how often the export worker looks for missed jobs and expired exports
+
+	UsernameReuseCooldown time.Duration // how long a username given up by one account is closed to others
+	UsernameMinLength     int           // characters of a username, at least
+	UsernameMaxLength     int           // characters of a username, at most
+	UsernameReserved      []string      // reserved usernames in addition to the built-in ones
+	UsernameBlocked       []string      // words no username may contain, in addition to the built-in ones
+}
+
+type PIIConfig struct {
+	KeysFile          string        // keyring of the field encryption keys, see `vayura keys rotate-pii`
+	ReencryptInterval time.Duration // how often values under retired keys are looked for
+	ReencryptBatch    int           // rows re-encrypted per query
+}
+
+type AuditConfig struct {
+	Retention       time.Duration // entries older than this are moved to ArchiveDir; 0 keeps them in the database
+	ArchiveDir      string        // gzipped JSON Lines files of archived entries
+	ArchiveInterval time.Duration // how often the archive worker looks for entries past Retention
+
+	CheckpointInterval time.Duration // how often the chain is verified and a signed checkpoint added
 }
 
 // Load reads configuration from environment variables
@@ -56,6 +150,8 @@ func Load() *Config {
 
 	return &Config{
 		Database: DatabaseConfig{
+			Driver:   getEnvOrDefault("DB_DRIVER", DriverPostgres),
+			Path:     getEnvOrDefault("DB_PATH", "vayura.db"),
 			Host:     getEnvOrDefault("DB_HOST", "localhost"),
 			User:     getEnvOrDefault("DB_USER", "postgres"),
 			Password: getEnvOrDefault("DB_PASSWORD", ""),
@@ -65,12 +161,71 @@ func Load() *Config {
 		JWT: JWTConfig{
 			Secret:    getEnvOrDefault("JWT_SECRET", "your-secret-key-change-in-production"),
 			ExpiresIn: getDurationEnvOrDefault("JWT_EXPIRES_IN", "72h"),
+			KeysFile:  getEnvOrDefault("JWT_KEYS_FILE", ""),
 		},
 		Server: ServerConfig{
-			Port: getEnvOrDefault("APP_PORT", "8080"),
+			Port:              getEnvOrDefault("APP_PORT", "8080"),
+			ReadHeaderTimeout: getDurationEnvOrDefault("SERVER_READ_HEADER_TIMEOUT", "10s"),
+			DrainDelay:        getDurationEnvOrDefault("SERVER_DRAIN_DELAY", "0s"),
+			ShutdownTimeout:   getDurationEnvOrDefault("SERVER_SHUTDOWN_TIMEOUT", "30s"),
 		},
 		Storage: StorageConfig{
 			UploadDir: getEnvOrDefault("UPLOAD_DIR", "Uploads/avatars"),
+			ExportDir: getEnvOrDefault("EXPORT_DIR", "Uploads/exports"),
+		},
+		Health: HealthConfig{
+			CacheTTL:     getDurationEnvOrDefault("HEALTH_CACHE_TTL", "2s"),
+			CheckTimeout: getDurationEnvOrDefault("HEALTH_CHECK_TIMEOUT", "2s"),
+		},
+		Mail: MailConfig{
+			Driver:       getEnvOrDefault("MAIL_DRIVER", MailDriverConsole),
+			From:         getEnvOrDefault("MAIL_FROM", "Vayura <no-reply@vayura.local>"),
+			Dir:          getEnvOrDefault("MAIL_DIR", ""),
+			SMTPHost:     getEnvOrDefault("SMTP_HOST", "localhost"),
+			SMTPPort:     getEnvOrDefault("SMTP_PORT", "587"),
+			SMTPUsername: getEnvOrDefault("SMTP_USERNAME", ""),
+			SMTPPassword: getEnvOrDefault("SMTP_PASSWORD", ""),
+		},
+		SMS: SMSConfig{
+			Driver:       getEnvOrDefault("SMS_DRIVER", SMSDriverConsole),
+			File:         getEnvOrDefault("SMS_FILE", ""),
+			Sender:       getEnvOrDefault("SMS_SENDER", "Vayura"),
+			GatewayURL:   getEnvOrDefault("SMS_GATEWAY_URL", ""),
+			GatewayToken: getEnvOrDefault("SMS_GATEWAY_TOKEN", ""),
+			Timeout:      getDurationEnvOrDefault("SMS_TIMEOUT", "10s"),
+		},
+		Account: AccountConfig{
+			PublicURL:           getEnvOrDefault("APP_PUBLIC_URL", "http://localhost:8080"),
+			EmailChangeTTL:      getDurationEnvOrDefault("EMAIL_CHANGE_TTL", "24h"),
+			EmailChangeCooldown: getDurationEnvOrDefault("EMAIL_CHANGE_COOLDOWN", "1h"),
+			PhoneRegion:         getEnvOrDefault("PHONE_DEFAULT_REGION", "ID"),
+			PhoneOTPTTL:         getDurationEnvOrDefault("PHONE_OTP_TTL", "5m"),
+			PhoneOTPCooldown:    getDurationEnvOrDefault("PHONE_OTP_COOLDOWN", "60s"),
+			PhoneOTPMaxAttempts: getIntEnvOrDefault("PHONE_OTP_MAX_ATTEMPTS", 5),
+			SettingsCacheTTL:    getDurationEnvOrDefault("SETTINGS_CACHE_TTL", "1m"),
+			SettingsCacheSize:   getIntEnvOrDefault("SETTINGS_CACHE_SIZE", 10000),
+			DeletionGrace:       getDurationEnvOrDefault("ACCOUNT_DELETION_GRACE", "720h"),
+			PurgeInterval:       getDurationEnvOrDefault("ACCOUNT_PURGE_INTERVAL", "1h"),
+			ExportTTL:           getDurationEnvOrDefault("EXPORT_TTL", "48h"),
+			ExportInterval:      getDurationEnvOrDefault("EXPORT_INTERVAL", "1m"),
+
+			UsernameReuseCooldown: getDurationEnvOrDefault("USERNAME_REUSE_COOLDOWN", "2160h"),
+			UsernameMinLength:     getIntEnvOrDefault("USERNAME_MIN_LENGTH", 3),
+			UsernameMaxLength:     getIntEnvOrDefault("USERNAME_MAX_LENGTH", 30),
+			UsernameReserved:      getListEnv("USERNAME_RESERVED"),
+			UsernameBlocked:       getListEnv("USERNAME_BLOCKLIST"),
+		},
+		PII: PIIConfig{
+			KeysFile:          getEnvOrDefault("PII_KEYS_FILE", "keys/pii.json"),
+			ReencryptInterval: getDurationEnvOrDefault("PII_REENCRYPT_INTERVAL", "1h"),
+			ReencryptBatch:    getIntEnvOrDefault("PII_REENCRYPT_BATCH", 200),
+		},
+		Audit: AuditConfig{
+			Retention:       getDurationEnvOrDefault("AUDIT_RETENTION", "8760h"),
+			ArchiveDir:      getEnvOrDefault("AUDIT_ARCHIVE_DIR", "audit"),
+			ArchiveInterval: getDurationEnvOrDefault("AUDIT_ARCHIVE_INTERVAL", "24h"),
+
+			CheckpointInterval: getDurationEnvOrDefault("AUDIT_CHECKPOINT_INTERVAL", "1h"),
 		},
 	}
 }
@@ -115,21 +270,69 @@ func InitDatabase(cfg DatabaseConfig) (*gorm.DB, error) {
 }
 
 func initDatabase(cfg DatabaseConfig) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		cfg.Host,
-		cfg.User,
-		cfg.Password,
-		cfg.DBName,
-		cfg.Port,
-	)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	var dialector gorm.Dialector
+	inMemory := false
+
+	switch cfg.Driver {
+	case DriverPostgres, "":
+		dsn := fmt.Sprintf(
+			"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+			cfg.Host,
+			cfg.User,
+			cfg.Password,
+			cfg.DBName,
+			cfg.Port,
+		)
+		dialector = postgres.Open(dsn)
+	case DriverSQLite:
+		// In-memory databases live per connection, so the pool is limited to a single one
+		inMemory = cfg.Path == ":memory:"
+		dsn := "file:" + cfg.Path + "?_foreign_keys=on&_busy_timeout=5000"
+		if !inMemory {
+			// Transactions take the write lock up front: a deferred one that reads and then writes
+			// fails with "database is locked" instead of waiting when another writer got there first
+			dsn += "&_journal_mode=WAL&_txlock=immediate"
+		}
+		dialector = sqlite.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent), // Set to logger.Info for SQL logging
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	if inMemory {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	}
+
 	return db, nil
 }
+
+func getIntEnvOrDefault(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getListEnv splits a comma-separated variable into its trimmed, non-empty items
+func getListEnv(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
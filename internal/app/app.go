@@ -0,0 +1,193 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+
+	"github.com/vayura/config"
+	"github.com/vayura/internal/mail"
+	"github.com/vayura/internal/migrate"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/internal/settings"
+	"github.com/vayura/internal/sms"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/fieldcrypt"
+	"github.com/vayura/pkg/identity"
+	"github.com/vayura/pkg/phone"
+	"gorm.io/gorm"
+)
+
+// App wires configuration, database, repositories and services; it is shared by the server and the CLI
+type App struct {
+	Config *config.Config
+	DB     *gorm.DB
+
+	UserRepo  repository.UserRepository
+	TxManager repository.TxManager
+	Mailer    mail.Mailer
+	SMSSender sms.SMSSender
+
+	AuthService        service.AuthService
+	UserService        service.UserService
+	AdminService       service.AdminService
+	StorageService     service.StorageService
+	SessionService     service.SessionService
+	EmailChangeService service.EmailChangeService
+	ProfileService     service.ProfileService
+	SettingsService    service.SettingsService
+	PurgeService       service.PurgeService
+	ExportService      service.ExportService
+	ReencryptService   service.ReencryptService
+	AuditService       service.AuditService
+	HistoryService     service.ProfileHistoryService
+	UsernamePolicy     service.UsernamePolicy
+
+	PhoneVerificationService service.PhoneVerificationService
+}
+
+// New connects to the database and builds the application services
+func New(cfg *config.Config) (*App, error) {
+	db, err := config.InitDatabase(cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	// Set legacy global DB for backward compatibility
+	config.DB = db
+	repository.SetDB(db)
+
+	// Initialize JWT secret, expiration and optional rotating keys
+	pkg.SetJWTSecret(cfg.JWT.Secret)
+	pkg.SetJWTExpiration(cfg.JWT.ExpiresIn)
+	if cfg.JWT.KeysFile != "" {
+		ks, err := pkg.LoadKeySet(cfg.JWT.KeysFile)
+		switch {
+		case err == nil:
+			pkg.SetJWTKeys(ks)
+		case errors.Is(err, fs.ErrNotExist):
+			log.Printf("⚠️  JWT keys file %s not found, signing with JWT_SECRET", cfg.JWT.KeysFile)
+		default:
+			return nil, fmt.Errorf("failed to load JWT keys: %w", err)
+		}
+	}
+
+	// Field encryption keys; a fresh install gets a new keyring
+	keyring, err := fieldcrypt.LoadKeyring(cfg.PII.KeysFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		if keyring, err = fieldcrypt.NewKeyring(); err == nil {
+			err = keyring.Save(cfg.PII.KeysFile)
+		}
+		if err == nil {
+			log.Printf("⚠️  PII keyring %s not found, generated a new one; back it up, encrypted data cannot be read without it", cfg.PII.KeysFile)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load PII keyring: %w", err)
+	}
+	fieldcrypt.SetKeyring(keyring)
+
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mailer: %w", err)
+	}
+	smsSender, err := sms.New(cfg.SMS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sms sender: %w", err)
+	}
+
+	// Phone numbers written without a country code belong to this region
+	if !phone.IsSupportedRegion(cfg.Account.PhoneRegion) {
+		return nil, fmt.Errorf("unsupported PHONE_DEFAULT_REGION %q", cfg.Account.PhoneRegion)
+	}
+	phone.SetDefaultRegion(cfg.Account.PhoneRegion)
+
+	if cfg.Account.UsernameMinLength < 1 || cfg.Account.UsernameMaxLength < cfg.Account.UsernameMinLength {
+		return nil, fmt.Errorf("invalid USERNAME_MIN_LENGTH %d and USERNAME_MAX_LENGTH %d", cfg.Account.UsernameMinLength, cfg.Account.UsernameMaxLength)
+	}
+	usernameRules := identity.NewUsernameRules(cfg.Account.UsernameMinLength, cfg.Account.UsernameMaxLength, cfg.Account.UsernameReserved, cfg.Account.UsernameBlocked)
+
+	// Initialize repositories
+	userRepo := repository.NewUserRepository(db)
+	txManager := repository.NewTxManager(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	emailChangeRepo := repository.NewEmailChangeRepository(db)
+	phoneVerificationRepo := repository.NewPhoneVerificationRepository(db)
+	privacyRepo := repository.NewPrivacyRepository(db)
+	settingsRepo := repository.NewSettingsRepository(db)
+	exportRepo := repository.NewDataExportRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	historyRepo := repository.NewProfileHistoryRepository(db)
+	grantRepo := repository.NewUsernameGrantRepository(db)
+
+	// Initialize services
+	auditService := service.NewAuditService(auditRepo, cfg.Audit)
+	historyService := service.NewProfileHistoryService(historyRepo, cfg.Account.UsernameReuseCooldown)
+	usernamePolicy := service.NewUsernamePolicy(usernameRules, userRepo, grantRepo, txManager, auditService, historyService)
+	authService := service.NewAuthService(userRepo, txManager, auditService, historyService, usernamePolicy, cfg.Account.DeletionGrace)
+	sessionService := service.NewSessionService(sessionRepo)
+	storageService := service.NewStorageService(cfg)
+	purgeService := service.NewPurgeService(userRepo, txManager, storageService, auditService, cfg.Account, auditRepo, historyRepo)
+	profileService := service.NewProfileService(userRepo, privacyRepo)
+	settingsService := service.NewSettingsService(settingsRepo, settings.Builtin(), settings.NewCache(cfg.Account.SettingsCacheTTL, cfg.Account.SettingsCacheSize))
+	exportService := service.NewExportService(userRepo, exportRepo, txManager, storageService, mailer, cfg.Storage.ExportDir, cfg.Account,
+		service.ProfileExport(userRepo),
+		service.SettingsExport(settingsService),
+		service.PrivacyExport(profileService),
+		service.SessionsExport(sessionRepo),
+		service.EmailChangesExport(emailChangeRepo),
+		service.PhoneVerificationsExport(phoneVerificationRepo),
+		service.AuditExport(auditRepo),
+		service.ProfileHistoryExport(historyService),
+	)
+
+	return &App{
+		Config:             cfg,
+		DB:                 db,
+		UserRepo:           userRepo,
+		TxManager:          txManager,
+		Mailer:             mailer,
+		SMSSender:          smsSender,
+		AuthService:        authService,
+		UserService:        service.NewUserService(userRepo, txManager, sessionService, auditService, historyService, usernamePolicy, cfg.Account.DeletionGrace),
+		AdminService:       service.NewAdminService(userRepo, txManager, authService, sessionService, purgeService, auditService),
+		StorageService:     storageService,
+		SessionService:     sessionService,
+		EmailChangeService: service.NewEmailChangeService(userRepo, emailChangeRepo, txManager, sessionService, auditService, historyService, mailer, cfg.Account),
+		ProfileService:     profileService,
+		SettingsService:    settingsService,
+		PurgeService:       purgeService,
+		ExportService:      exportService,
+		ReencryptService:   service.NewReencryptService(repository.NewEncryptionRepository(db), cfg.PII),
+		AuditService:       auditService,
+		HistoryService:     historyService,
+		UsernamePolicy:     usernamePolicy,
+
+		PhoneVerificationService: service.NewPhoneVerificationService(userRepo, phoneVerificationRepo, txManager, auditService, smsSender, cfg.Account),
+	}, nil
+}
+
+// Migrator returns the schema migrator for the app database
+func (a *App) Migrator() (*migrate.Migrator, error) {
+	return migrate.New(a.DB)
+}
+
+// Close releases the database pool and the connections of the SMS and mail clients; closing
+// twice is harmless
+func (a *App) Close() error {
+	for _, client := range []interface{}{a.SMSSender, a.Mailer} {
+		if c, ok := client.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				log.Printf("⚠️  Failed to close %T: %v", client, err)
+			}
+		}
+	}
+	sqlDB, err := a.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
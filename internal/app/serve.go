@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vayura/internal/handler"
+	"github.com/vayura/internal/health"
+	"github.com/vayura/internal/server"
+	"github.com/vayura/internal/settings"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/i18n"
+	"github.com/vayura/routes"
+)
+
+// ServeOptions controls server startup
+type ServeOptions struct {
+	VerifySchema bool // only verify migrations instead of applying them
+}
+
+// Serve migrates (or verifies) the schema and runs the HTTP server until shutdown
+func (a *App) Serve(ctx context.Context, opts ServeOptions) error {
+	// Refuse to start with incomplete message catalogs
+	if err := i18n.Validate(); err != nil {
+		return err
+	}
+
+	// Run or verify migrations
+	migrator, err := a.Migrator()
+	if err != nil {
+		return err
+	}
+	if opts.VerifySchema {
+		if err := migrator.Verify(ctx); err != nil {
+			return err
+		}
+		log.Println("✅ Database schema verified")
+	} else {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("✅ Database migrations completed (%d applied)", len(applied))
+	}
+
+	// Setup server lifecycle
+	srv := server.New(a.Config.Server)
+	srv.OnShutdown("database pool", func(ctx context.Context) error {
+		return a.Close()
+	})
+	srv.Go("account purge", a.PurgeService.Run)
+	srv.Go("data export", a.ExportService.Run)
+	srv.Go("re-encryption", a.ReencryptService.Run)
+	srv.Go("audit archive", a.AuditService.Run)
+
+	// Register health checks
+	healthRegistry := health.NewRegistry(a.Config.Health.CacheTTL, a.Config.Health.CheckTimeout)
+	healthRegistry.Register(health.DatabaseCheck(a.DB))
+	healthRegistry.Register(health.StorageCheck(a.Config.Storage.UploadDir))
+	healthRegistry.Register(health.MigrationsCheck(migrator.Verify))
+	healthRegistry.Register(health.MailCheck(a.Mailer.Check))
+
+	// Initialize handlers
+	authHandler := handler.NewAuthHandler(a.AuthService, a.SessionService)
+	userHandler := handler.NewUserHandler(a.UserService, a.StorageService)
+	emailChangeHandler := handler.NewEmailChangeHandler(a.EmailChangeService)
+	phoneHandler := handler.NewPhoneHandler(a.PhoneVerificationService)
+	profileHandler := handler.NewProfileHandler(a.ProfileService)
+	settingsHandler := handler.NewSettingsHandler(a.SettingsService)
+	exportHandler := handler.NewExportHandler(a.ExportService)
+	auditHandler := handler.NewAuditHandler(a.AuditService)
+	historyHandler := handler.NewProfileHistoryHandler(a.HistoryService)
+	grantHandler := handler.NewUsernameGrantHandler(a.UsernamePolicy)
+	healthHandler := handler.NewHealthHandler(healthRegistry, srv.Ready)
+
+	// Setup router and routes; every token must belong to an active session
+	pkg.SetupValidator()
+	pkg.SetSessionValidator(a.SessionService.Validate)
+	pkg.SetRoleLookup(a.userRole)
+	pkg.SetLocalePreference(a.languagePreference)
+	r := gin.Default()
+	r.Use(pkg.RequestID(), pkg.ActorMiddleware(), pkg.ErrorHandler())
+	routes.SetupRoutes(r, authHandler, userHandler, emailChangeHandler, phoneHandler, profileHandler, settingsHandler, exportHandler, auditHandler, historyHandler, grantHandler, healthHandler)
+	srv.SetHandler(r)
+
+	// Start server and block until shutdown completes
+	return srv.Run(ctx)
+}
+
+// languagePreference returns the language setting of the authenticated user, empty for anonymous
+// requests or when it cannot be read, so the locale falls back to Accept-Language
+func (a *App) languagePreference(c *gin.Context) string {
+	userID, ok := pkg.GetUserID(c)
+	if !ok {
+		return ""
+	}
+	language, err := a.SettingsService.String(c.Request.Context(), userID, settings.KeyLanguage)
+	if err != nil {
+		log.Printf("⚠️  Failed to read language setting of user %d: %v", userID, err)
+		return ""
+	}
+	return language
+}
+
+// userRole returns the current role of a user for pkg.RequireRole
+func (a *App) userRole(ctx context.Context, userID uint) (string, error) {
+	user, err := a.UserRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return user.Role, nil
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/i18n"
+)
+
+// AuditHandler handles the admin audit log endpoints
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// List returns audit entries, newest first. Filters: actor_id, target_id, action, request_id,
+// from and to (RFC 3339 or YYYY-MM-DD); pages with limit and before, the next_before of the previous page.
+func (h *AuditHandler) List(c *gin.Context) {
+	filter, err := auditFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	entries, err := h.auditService.List(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := gin.H{"entries": entries}
+	if filter.Limit > 0 && len(entries) == filter.Limit {
+		response["next_before"] = entries[len(entries)-1].ID
+	}
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgAuditFetched, response)
+}
+
+// auditFilter reads the audit filter from the query string
+func auditFilter(c *gin.Context) (repository.AuditFilter, error) {
+	var details []pkg.ErrorDetail
+	id := func(name string) uint {
+		value := c.Query(name)
+		if value == "" {
+			return 0
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || n == 0 {
+			details = append(details, pkg.ErrorDetail{Field: name, Rule: "type", Param: "positive integer", Message: name + " must be a positive integer"})
+		}
+		return uint(n)
+	}
+	date := func(name string) time.Time {
+		value := c.Query(name)
+		if value == "" {
+			return time.Time{}
+		}
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t
+		}
+		t, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			details = append(details, pkg.ErrorDetail{Field: name, Rule: "datetime", Param: "RFC 3339 or YYYY-MM-DD", Message: name + " must be an RFC 3339 time or a YYYY-MM-DD date"})
+		}
+		return t
+	}
+
+	filter := repository.AuditFilter{
+		ActorID:   id("actor_id"),
+		TargetID:  id("target_id"),
+		Action:    c.Query("action"),
+		RequestID: c.Query("request_id"),
+		From:      date("from"),
+		To:        date("to"),
+		BeforeID:  id("before"),
+		Limit:     service.DefaultAuditLimit,
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > service.MaxAuditLimit {
+			param := "1 - " + strconv.Itoa(service.MaxAuditLimit)
+			details = append(details, pkg.ErrorDetail{Field: "limit", Rule: "range", Param: param, Message: "limit must be between " + param})
+		}
+		filter.Limit = limit
+	}
+
+	if len(details) > 0 {
+		return filter, &pkg.Error{Kind: pkg.ErrValidation, Code: pkg.CodeValidationFailed, Message: "request validation failed", Details: details}
+	}
+	return filter, nil
+}
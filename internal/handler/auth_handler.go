@@ -4,28 +4,30 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vayura/internal/models"
 	"github.com/vayura/internal/service"
 	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/i18n"
 )
 
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
-	authService service.AuthService
+	authService    service.AuthService
+	sessionService service.SessionService
 }
 
 // NewAuthHandler creates a new authentication handler
-func NewAuthHandler(authService service.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
+func NewAuthHandler(authService service.AuthService, sessionService service.SessionService) *AuthHandler {
+	return &AuthHandler{authService: authService, sessionService: sessionService}
 }
 
 // RegisterRequest represents the registration request
 type RegisterRequest struct {
 	FullName string `json:"full_name" binding:"required,min=3"`
-	Username string `json:"username" binding:"required,min=3"`
+	Username string `json:"username" binding:"required"` // length and format are checked by the username policy
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=8"`
 	Phone    string `json:"phone"`
-	Role     string `json:"role"`
 	Gender   string `json:"gender"`
 	Birthday string `json:"birthday"` // format YYYY-MM-DD
 }
@@ -34,64 +36,62 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	Restore  bool   `json:"restore"` // restore an account scheduled for deletion
 }
 
 // Register handles user registration
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		pkg.JSONBadRequest(c, err)
+		c.Error(pkg.BindError(err))
 		return
 	}
 
-	// Convert to service request
+	// Convert to service request; self-registered accounts always get the user role
 	serviceReq := service.RegisterRequest{
 		FullName: req.FullName,
 		Username: req.Username,
 		Email:    req.Email,
 		Password: req.Password,
 		Phone:    req.Phone,
-		Role:     req.Role,
+		Role:     models.RoleUser,
 		Gender:   req.Gender,
 		Birthday: req.Birthday,
 	}
 
 	user, err := h.authService.Register(c.Request.Context(), serviceReq)
 	if err != nil {
-		if err == pkg.ErrEmailExists || err == pkg.ErrUsernameExists {
-			pkg.JSONBadRequest(c, err)
-		} else {
-			pkg.JSONInternalServerError(c, err)
-		}
+		c.Error(err)
 		return
 	}
 
-	pkg.JSONSuccess(c, http.StatusCreated, "user registered successfully", user)
+	pkg.JSONSuccess(c, http.StatusCreated, i18n.MsgUserRegistered, user)
 }
 
 // Login handles user login
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		pkg.JSONBadRequest(c, err)
+		c.Error(pkg.BindError(err))
 		return
 	}
 
 	serviceReq := service.LoginRequest{
 		Email:    req.Email,
 		Password: req.Password,
+		Restore:  req.Restore,
 	}
 
 	user, err := h.authService.Login(c.Request.Context(), serviceReq)
 	if err != nil {
-		pkg.JSONUnauthorized(c, err)
+		c.Error(err)
 		return
 	}
 
-	// Generate JWT
-	token, err := pkg.GenerateJWT(user.ID, user.Email)
+	// Start a session and generate its JWT
+	token, err := h.sessionService.Start(c.Request.Context(), user, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
-		pkg.JSONInternalServerError(c, err)
+		c.Error(err)
 		return
 	}
 
@@ -99,16 +99,17 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	response := gin.H{
 		"token": token,
 		"user": gin.H{
-			"id":        user.ID,
-			"full_name": user.FullName,
-			"username":  user.Username,
-			"email":     user.Email,
-			"phone":     user.Phone,
-			"role":      user.Role,
-			"gender":    user.Gender,
-			"birthday":  user.Birthday,
+			"id":                user.ID,
+			"full_name":         user.FullName,
+			"username":          user.Username,
+			"email":             user.Email,
+			"phone":             user.Phone,
+			"phone_verified_at": user.PhoneVerifiedAt,
+			"role":              user.Role,
+			"gender":            user.Gender,
+			"birthday":          user.Birthday,
 		},
 	}
 
-	pkg.JSONSuccess(c, http.StatusOK, "login successful", response)
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgLoginSuccessful, response)
 }
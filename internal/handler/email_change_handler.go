@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/i18n"
+)
+
+// EmailChangeHandler handles email change endpoints
+type EmailChangeHandler struct {
+	emailChangeService service.EmailChangeService
+}
+
+// NewEmailChangeHandler creates a new email change handler
+func NewEmailChangeHandler(emailChangeService service.EmailChangeService) *EmailChangeHandler {
+	return &EmailChangeHandler{emailChangeService: emailChangeService}
+}
+
+// EmailChangeTokenRequest carries the token of an emailed confirm or cancel link
+type EmailChangeTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// Request starts an email change for the authenticated user
+func (h *EmailChangeHandler) Request(c *gin.Context) {
+	userID, exists := pkg.GetUserID(c)
+	if !exists {
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	var req service.EmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(pkg.BindError(err))
+		return
+	}
+
+	if err := h.emailChangeService.Request(c.Request.Context(), userID, req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusAccepted, i18n.MsgEmailChangeRequested, nil)
+}
+
+// Confirm applies the email change of a confirmation link
+func (h *EmailChangeHandler) Confirm(c *gin.Context) {
+	var req EmailChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(pkg.BindError(err))
+		return
+	}
+
+	if err := h.emailChangeService.Confirm(c.Request.Context(), req.Token); err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgEmailChanged, nil)
+}
+
+// Cancel cancels the email change of a cancel link
+func (h *EmailChangeHandler) Cancel(c *gin.Context) {
+	var req EmailChangeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(pkg.BindError(err))
+		return
+	}
+
+	if err := h.emailChangeService.Cancel(c.Request.Context(), req.Token); err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgEmailChangeCancelled, nil)
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/i18n"
+)
+
+// ExportHandler handles personal data export endpoints
+type ExportHandler struct {
+	exportService service.ExportService
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(exportService service.ExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// Request queues a data export for the authenticated user
+func (h *ExportHandler) Request(c *gin.Context) {
+	userID, exists := pkg.GetUserID(c)
+	if !exists {
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	export, err := h.exportService.Request(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusAccepted, i18n.MsgExportRequested, export)
+}
+
+// Latest returns the state of the authenticated user's most recent data export
+func (h *ExportHandler) Latest(c *gin.Context) {
+	userID, exists := pkg.GetUserID(c)
+	if !exists {
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	export, err := h.exportService.Latest(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgExportFetched, export)
+}
+
+// Download serves the archive of an emailed download link
+func (h *ExportHandler) Download(c *gin.Context) {
+	export, err := h.exportService.Open(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.FileAttachment(export.File, fmt.Sprintf("vayura-export-%d.zip", export.ID))
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vayura/internal/health"
+)
+
+// HealthHandler exposes liveness and readiness probes
+type HealthHandler struct {
+	registry *health.Registry
+	ready    func() bool
+}
+
+// NewHealthHandler creates a new health handler; ready reports the server lifecycle state
+func NewHealthHandler(registry *health.Registry, ready func() bool) *HealthHandler {
+	return &HealthHandler{registry: registry, ready: ready}
+}
+
+// Livez reports whether the process is alive
+func (h *HealthHandler) Livez(c *gin.Context) {
+	h.respond(c, h.registry.Liveness(c.Request.Context()))
+}
+
+// Readyz reports whether the process can serve traffic
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	if !h.ready() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting_down"})
+		return
+	}
+	h.respond(c, h.registry.Readiness(c.Request.Context()))
+}
+
+func (h *HealthHandler) respond(c *gin.Context, report health.Report) {
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+
+	if c.Query("verbose") != "1" {
+		report.Checks = nil
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/i18n"
+)
+
+// PhoneHandler handles phone verification endpoints
+type PhoneHandler struct {
+	phoneVerificationService service.PhoneVerificationService
+}
+
+// NewPhoneHandler creates a new phone verification handler
+func NewPhoneHandler(phoneVerificationService service.PhoneVerificationService) *PhoneHandler {
+	return &PhoneHandler{phoneVerificationService: phoneVerificationService}
+}
+
+// SendCode texts a verification code to the authenticated user's phone number
+func (h *PhoneHandler) SendCode(c *gin.Context) {
+	userID, exists := pkg.GetUserID(c)
+	if !exists {
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	if err := h.phoneVerificationService.SendCode(c.Request.Context(), userID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusAccepted, i18n.MsgPhoneOTPSent, nil)
+}
+
+// Verify marks the phone number verified when the code matches
+func (h *PhoneHandler) Verify(c *gin.Context) {
+	userID, exists := pkg.GetUserID(c)
+	if !exists {
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	var req service.PhoneVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(pkg.BindError(err))
+		return
+	}
+
+	user, err := h.phoneVerificationService.Verify(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.SetETag(c, user.Version)
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgPhoneVerified, user)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/i18n"
+)
+
+// ProfileHandler handles public profiles, profile visibility, contacts and blocks
+type ProfileHandler struct {
+	profileService service.ProfileService
+}
+
+// NewProfileHandler creates a new profile handler
+func NewProfileHandler(profileService service.ProfileService) *ProfileHandler {
+	return &ProfileHandler{profileService: profileService}
+}
+
+// PublicProfile returns the profile of :username as the caller (anonymous or authenticated) may see it
+func (h *ProfileHandler) PublicProfile(c *gin.Context) {
+	viewerID, _ := pkg.GetUserID(c)
+
+	profile, err := h.profileService.PublicProfile(c.Request.Context(), viewerID, c.Param("username"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgProfileFetched, profile)
+}
+
+// GetVisibility returns the visibility of every profile field of the authenticated user
+func (h *ProfileHandler) GetVisibility(c *gin.Context) {
+	userID, exists := pkg.GetUserID(c)
+	if !exists {
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	visibility, err := h.profileService.Visibility(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgVisibilityFetched, visibility)
+}
+
+// UpdateVisibility changes the visibility of the fields in the body, e.g. {"email": "contacts"}
+func (h *ProfileHandler) UpdateVisibility(c *gin.Context) {
+	userID, exists := pkg.GetUserID(c)
+	if !exists {
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	var changes map[string]string
+	if err := c.ShouldBindJSON(&changes); err != nil {
+		c.Error(pkg.BindError(err))
+		return
+	}
+
+	visibility, err := h.profileService.UpdateVisibility(c.Request.Context(), userID, changes)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgVisibilityUpdated, visibility)
+}
+
+// ListContacts returns the usernames of the authenticated user's contacts
+func (h *ProfileHandler) ListContacts(c *gin.Context) {
+	h.listRelated(c, models.RelationContact, i18n.MsgContactsFetched)
+}
+
+// AddContact lets :username see the fields visible to contacts
+func (h *ProfileHandler) AddContact(c *gin.Context) {
+	h.addRelation(c, models.RelationContact, i18n.MsgContactAdded)
+}
+
+// RemoveContact removes :username from the contacts
+func (h *ProfileHandler) RemoveContact(c *gin.Context) {
+	h.removeRelation(c, models.RelationContact, i18n.MsgContactRemoved)
+}
+
+// ListBlocks returns the usernames of the users the authenticated user blocked
+func (h *ProfileHandler) ListBlocks(c *gin.Context) {
+	h.listRelated(c, models.RelationBlock, i18n.MsgBlocksFetched)
+}
+
+// Block hides the authenticated user's public profile from :username
+func (h *ProfileHandler) Block(c *gin.Context) {
+	h.addRelation(c, models.RelationBlock, i18n.MsgUserBlocked)
+}
+
+// Unblock lifts a block of :username
+func (h *ProfileHandler) Unblock(c *gin.Context) {
+	h.removeRelation(c, models.RelationBlock, i18n.MsgUserUnblocked)
+}
+
+func (h *ProfileHandler) listRelated(c *gin.Context, kind, message string) {
+	userID, exists := pkg.GetUserID(c)
+	if !exists {
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	usernames, err := h.profileService.ListRelated(c.Request.Context(), userID, kind)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusOK, message, usernames)
+}
+
+func (h *ProfileHandler) addRelation(c *gin.Context, kind, message string) {
+	userID, exists := pkg.GetUserID(c)
+	if !exists {
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	if err := h.profileService.AddRelation(c.Request.Context(), userID, c.Param("username"), kind); err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusOK, message, nil)
+}
+
+func (h *ProfileHandler) removeRelation(c *gin.Context, kind, message string) {
+	userID, exists := pkg.GetUserID(c)
+	if !exists {
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	if err := h.profileService.RemoveRelation(c.Request.Context(), userID, c.Param("username"), kind); err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusOK, message, nil)
+}
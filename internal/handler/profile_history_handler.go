@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/i18n"
+)
+
+// ProfileHistoryHandler handles the admin profile history endpoints
+type ProfileHistoryHandler struct {
+	historyService service.ProfileHistoryService
+}
+
+// NewProfileHistoryHandler creates a new profile history handler
+func NewProfileHistoryHandler(historyService service.ProfileHistoryService) *ProfileHistoryHandler {
+	return &ProfileHistoryHandler{historyService: historyService}
+}
+
+// List returns the profile versions of a user, newest first, paged with limit and before, the
+// next_before of the previous page. With as_of (RFC 3339 or YYYY-MM-DD) it returns the profile
+// as it was at that time instead.
+func (h *ProfileHistoryHandler) List(c *gin.Context) {
+	var details []pkg.ErrorDetail
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || userID == 0 {
+		details = append(details, pkg.ErrorDetail{Field: "id", Rule: "type", Param: "positive integer", Message: "id must be a positive integer"})
+	}
+	var asOf time.Time
+	if value := c.Query("as_of"); value != "" {
+		if asOf, err = time.Parse(time.RFC3339, value); err != nil {
+			// A date means the end of that day
+			date, dateErr := time.Parse("2006-01-02", value)
+			asOf = date.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			if dateErr != nil {
+				details = append(details, pkg.ErrorDetail{Field: "as_of", Rule: "datetime", Param: "RFC 3339 or YYYY-MM-DD", Message: "as_of must be an RFC 3339 time or a YYYY-MM-DD date"})
+			}
+		}
+	}
+	var beforeID uint64
+	if value := c.Query("before"); value != "" {
+		if beforeID, err = strconv.ParseUint(value, 10, 64); err != nil || beforeID == 0 {
+			details = append(details, pkg.ErrorDetail{Field: "before", Rule: "type", Param: "positive integer", Message: "before must be a positive integer"})
+		}
+	}
+	limit := service.DefaultHistoryLimit
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > service.MaxHistoryLimit {
+			param := "1 - " + strconv.Itoa(service.MaxHistoryLimit)
+			details = append(details, pkg.ErrorDetail{Field: "limit", Rule: "range", Param: param, Message: "limit must be between " + param})
+		}
+	}
+	if len(details) > 0 {
+		c.Error(&pkg.Error{Kind: pkg.ErrValidation, Code: pkg.CodeValidationFailed, Message: "request validation failed", Details: details})
+		return
+	}
+
+	if !asOf.IsZero() {
+		version, err := h.historyService.AsOf(c.Request.Context(), uint(userID), asOf)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		pkg.JSONSuccess(c, http.StatusOK, i18n.MsgProfileHistoryFetched, gin.H{"as_of": asOf, "profile": version})
+		return
+	}
+
+	versions, err := h.historyService.List(c.Request.Context(), uint(userID), uint(beforeID), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response := gin.H{"versions": versions}
+	if len(versions) == limit {
+		response["next_before"] = versions[len(versions)-1].ID
+	}
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgProfileHistoryFetched, response)
+}
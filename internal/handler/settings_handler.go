@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/i18n"
+)
+
+// SettingsHandler handles user settings endpoints
+type SettingsHandler struct {
+	settingsService service.SettingsService
+}
+
+// NewSettingsHandler creates a new settings handler
+func NewSettingsHandler(settingsService service.SettingsService) *SettingsHandler {
+	return &SettingsHandler{settingsService: settingsService}
+}
+
+// GetSettings returns every setting of the authenticated user, defaults included
+func (h *SettingsHandler) GetSettings(c *gin.Context) {
+	userID, exists := pkg.GetUserID(c)
+	if !exists {
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	values, err := h.settingsService.All(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgSettingsFetched, values)
+}
+
+// UpdateSettings applies a merge patch of settings; null resets a setting to its default
+func (h *SettingsHandler) UpdateSettings(c *gin.Context) {
+	userID, exists := pkg.GetUserID(c)
+	if !exists {
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	var patch map[string]json.RawMessage
+	err := c.ShouldBindJSON(&patch)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) || (err == nil && patch == nil) {
+		c.Error(errPatchNotObject)
+		return
+	}
+	if err != nil {
+		c.Error(pkg.BindError(err))
+		return
+	}
+
+	values, err := h.settingsService.Update(c.Request.Context(), userID, patch)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgSettingsUpdated, values)
+}
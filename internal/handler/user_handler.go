@@ -1,13 +1,20 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/vayura/internal/service"
 	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/i18n"
 )
 
+// errPatchNotObject rejects merge patches that would replace the whole resource
+var errPatchNotObject = pkg.NewError(pkg.ErrValidation, pkg.CodeInvalidJSON, "request body must be a JSON object")
+
 type UserHandler struct {
 	userService    service.UserService
 	storageService service.StorageService
@@ -25,87 +32,158 @@ func NewUserHandler(userService service.UserService, storageService service.Stor
 func (h *UserHandler) GetProfile(c *gin.Context) {
 	userID, exists := pkg.GetUserID(c)
 	if !exists {
-		pkg.JSONUnauthorized(c, pkg.ErrInvalidToken)
+		c.Error(pkg.ErrInvalidToken)
 		return
 	}
 
 	user, err := h.userService.GetProfile(c.Request.Context(), userID)
 	if err != nil {
-		pkg.JSONInternalServerError(c, err)
+		c.Error(err)
 		return
 	}
 
-	pkg.JSONSuccess(c, http.StatusOK, "profile fetched successfully", user)
+	pkg.SetETag(c, user.Version)
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgProfileFetched, user)
 }
 
 // UpdateProfile updates user profile fields
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	userID, exists := pkg.GetUserID(c)
 	if !exists {
-		pkg.JSONUnauthorized(c, pkg.ErrInvalidToken)
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	version, err := pkg.IfMatch(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	var req service.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		pkg.JSONBadRequest(c, err)
+		c.Error(pkg.BindError(err))
 		return
 	}
 
-	user, err := h.userService.UpdateProfile(c.Request.Context(), userID, req)
+	user, err := h.userService.UpdateProfile(c.Request.Context(), userID, version, req)
 	if err != nil {
-		if err == pkg.ErrUsernameExists {
-			pkg.JSONBadRequest(c, err)
-			return
-		}
-		pkg.JSONInternalServerError(c, err)
+		c.Error(err)
+		return
+	}
+
+	pkg.SetETag(c, user.Version)
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgProfileUpdated, user)
+}
+
+// PatchProfile applies a JSON Merge Patch to the profile; null clears a field
+func (h *UserHandler) PatchProfile(c *gin.Context) {
+	userID, exists := pkg.GetUserID(c)
+	if !exists {
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	version, err := pkg.IfMatch(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var patch service.ProfilePatch
+	err = c.ShouldBindJSON(&patch)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) || (err == nil && patch == nil) {
+		c.Error(errPatchNotObject)
+		return
+	}
+	if err != nil {
+		c.Error(pkg.BindError(err))
+		return
+	}
+
+	user, err := h.userService.PatchProfile(c.Request.Context(), userID, version, patch)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	pkg.JSONSuccess(c, http.StatusOK, "profile updated successfully", user)
+	pkg.SetETag(c, user.Version)
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgProfileUpdated, user)
 }
 
-// DeleteProfile deletes the authenticated user's profile
+// DeleteProfile schedules the authenticated user's account for deletion
 func (h *UserHandler) DeleteProfile(c *gin.Context) {
 	userID, exists := pkg.GetUserID(c)
 	if !exists {
-		pkg.JSONUnauthorized(c, pkg.ErrInvalidToken)
+		c.Error(pkg.ErrInvalidToken)
 		return
 	}
 
-	if err := h.userService.DeleteProfile(c.Request.Context(), userID); err != nil {
-		pkg.JSONInternalServerError(c, err)
+	version, err := pkg.IfMatch(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	purgeAt, err := h.userService.DeleteProfile(c.Request.Context(), userID, version)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	pkg.JSONSuccess(c, http.StatusOK, "profile deleted successfully", nil)
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgProfileDeleted, gin.H{"purge_at": purgeAt})
 }
 
 // UploadAvatar handles avatar upload
 func (h *UserHandler) UploadAvatar(c *gin.Context) {
 	userID, exists := pkg.GetUserID(c)
 	if !exists {
-		pkg.JSONUnauthorized(c, pkg.ErrInvalidToken)
+		c.Error(pkg.ErrInvalidToken)
+		return
+	}
+
+	version, err := pkg.IfMatch(c)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	file, err := c.FormFile("avatar")
 	if err != nil {
-		pkg.JSONBadRequest(c, err)
+		c.Error(pkg.BindError(err))
 		return
 	}
 
+	// fail a stale precondition before the file is stored; UpdateAvatar checks it again
+	if version != 0 {
+		current, err := h.userService.GetProfile(c.Request.Context(), userID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if current.Version != version {
+			c.Error(pkg.ErrVersionMismatch)
+			return
+		}
+	}
+
 	avatarPath, err := h.storageService.SaveAvatar(c.Request.Context(), userID, file)
 	if err != nil {
-		pkg.JSONBadRequest(c, err)
+		c.Error(err)
 		return
 	}
 
-	user, err := h.userService.UpdateAvatar(c.Request.Context(), userID, avatarPath)
+	user, err := h.userService.UpdateAvatar(c.Request.Context(), userID, version, avatarPath)
 	if err != nil {
-		pkg.JSONInternalServerError(c, err)
+		// the profile does not point at the new file, so it would never be served or replaced
+		if removeErr := h.storageService.RemoveAvatar(c.Request.Context(), avatarPath); removeErr != nil {
+			log.Printf("⚠️  Failed to remove avatar %s of user %d: %v", avatarPath, userID, removeErr)
+		}
+		c.Error(err)
 		return
 	}
 
-	pkg.JSONSuccess(c, http.StatusOK, "avatar updated successfully", user)
+	pkg.SetETag(c, user.Version)
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgAvatarUpdated, user)
 }
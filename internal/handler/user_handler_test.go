@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vayura/config"
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+)
+
+// avatarUserService serves a user at version 1 and lets only the first avatar update win, like
+// two uploads that both passed the If-Match check before either was written
+type avatarUserService struct {
+	service.UserService
+	avatar string
+}
+
+func (s *avatarUserService) GetProfile(ctx context.Context, userID uint) (*models.User, error) {
+	return &models.User{ID: userID, Version: 1, Avatar: s.avatar}, nil
+}
+
+func (s *avatarUserService) UpdateAvatar(ctx context.Context, userID uint, version int64, avatarPath string) (*models.User, error) {
+	if s.avatar != "" {
+		return nil, pkg.ErrVersionMismatch
+	}
+	s.avatar = avatarPath
+	return &models.User{ID: userID, Version: 2, Avatar: avatarPath}, nil
+}
+
+func uploadAvatar(t *testing.T, router *gin.Engine, ifMatch string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("avatar", "me.png")
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	part.Write([]byte("\x89PNG\r\n\x1a\n"))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user/avatar", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("If-Match", ifMatch)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUploadAvatarLosingUpdateKeepsWinnersFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	users := &avatarUserService{}
+	h := NewUserHandler(users, service.NewStorageService(&config.Config{Storage: config.StorageConfig{UploadDir: dir}}))
+
+	router := gin.New()
+	router.Use(pkg.ErrorHandler(), func(c *gin.Context) { c.Set("userID", uint(7)) })
+	router.POST("/api/user/avatar", h.UploadAvatar)
+
+	// Both uploads land in the same second with the same extension
+	if rec := uploadAvatar(t, router, `"1"`); rec.Code != http.StatusOK {
+		t.Fatalf("first upload = %d %s, want 200", rec.Code, rec.Body)
+	}
+	if rec := uploadAvatar(t, router, `"1"`); rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("second upload = %d %s, want 412", rec.Code, rec.Body)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read upload dir: %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("upload dir holds %d files, want only the winner's avatar", len(files))
+	}
+	if !strings.HasSuffix(users.avatar, "/"+files[0].Name()) {
+		t.Fatalf("stored avatar %s, want the profile's %s", files[0].Name(), users.avatar)
+	}
+}
+
+func TestUploadAvatarRejectsWeakIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewUserHandler(&avatarUserService{}, service.NewStorageService(&config.Config{Storage: config.StorageConfig{UploadDir: t.TempDir()}}))
+
+	router := gin.New()
+	router.Use(pkg.ErrorHandler(), func(c *gin.Context) { c.Set("userID", uint(7)) })
+	router.POST("/api/user/avatar", h.UploadAvatar)
+
+	for header, want := range map[string]int{
+		`W/"1"`: http.StatusPreconditionFailed,
+		`1`:     http.StatusBadRequest,
+		`"2"`:   http.StatusPreconditionFailed,
+	} {
+		if rec := uploadAvatar(t, router, header); rec.Code != want {
+			t.Fatalf("If-Match %s = %d %s, want %d", header, rec.Code, rec.Body, want)
+		}
+	}
+}
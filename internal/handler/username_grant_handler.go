@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/i18n"
+)
+
+// UsernameGrantHandler handles the admin endpoints of reserved username grants
+type UsernameGrantHandler struct {
+	usernamePolicy service.UsernamePolicy
+}
+
+// NewUsernameGrantHandler creates a new username grant handler
+func NewUsernameGrantHandler(usernamePolicy service.UsernamePolicy) *UsernameGrantHandler {
+	return &UsernameGrantHandler{usernamePolicy: usernamePolicy}
+}
+
+// GrantUsernameRequest names the user a reserved username is granted to
+type GrantUsernameRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// List returns every grant ordered by username
+func (h *UsernameGrantHandler) List(c *gin.Context) {
+	grants, err := h.usernamePolicy.ListGrants(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgUsernameGrantsFetched, gin.H{"grants": grants})
+}
+
+// Grant allows a user to take the reserved username in the path
+func (h *UsernameGrantHandler) Grant(c *gin.Context) {
+	var req GrantUsernameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(pkg.BindError(err))
+		return
+	}
+
+	grant, err := h.usernamePolicy.Grant(c.Request.Context(), c.Param("username"), req.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgUsernameGranted, grant)
+}
+
+// Revoke removes the grant of the username in the path
+func (h *UsernameGrantHandler) Revoke(c *gin.Context) {
+	if err := h.usernamePolicy.Revoke(c.Request.Context(), c.Param("username")); err != nil {
+		c.Error(err)
+		return
+	}
+	pkg.JSONSuccess(c, http.StatusOK, i18n.MsgUsernameGrantRevoked, nil)
+}
@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseCheck pings the database connection pool
+func DatabaseCheck(db *gorm.DB) Check {
+	return Check{
+		Name:     "database",
+		Critical: true,
+		Run: func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+	}
+}
+
+// StorageCheck verifies that the upload directory exists and is writable
+func StorageCheck(dir string) Check {
+	return Check{
+		Name:     "storage",
+		Critical: true,
+		Run: func(ctx context.Context) error {
+			if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+				return fmt.Errorf("upload directory unavailable: %w", err)
+			}
+			f, err := os.CreateTemp(dir, ".healthcheck-*")
+			if err != nil {
+				return fmt.Errorf("upload directory not writable: %w", err)
+			}
+			name := f.Name()
+			f.Close()
+			return os.Remove(filepath.Clean(name))
+		},
+	}
+}
+
+// MigrationsCheck verifies that the schema is up to date with the embedded migrations
+func MigrationsCheck(verify func(ctx context.Context) error) Check {
+	return Check{
+		Name:     "migrations",
+		Critical: true,
+		Run:      verify,
+	}
+}
+
+// MailCheck verifies that the mailer can deliver; mail is not needed to serve most requests, so it is not critical
+func MailCheck(check func(ctx context.Context) error) Check {
+	return Check{
+		Name: "mail",
+		Run:  check,
+	}
+}
@@ -0,0 +1,170 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status values reported by checks and reports
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check is a single dependency check registered by a component
+type Check struct {
+	Name     string
+	Critical bool          // a failing critical check makes the report fail
+	Liveness bool          // also run the check for /livez, not only /readyz
+	Timeout  time.Duration // per-check deadline, defaults to the registry timeout
+	Run      func(ctx context.Context) error
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report aggregates the results of a run
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Healthy reports whether no critical check failed
+func (r Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+// Registry holds the registered checks and caches their results briefly
+type Registry struct {
+	mu       sync.Mutex
+	checks   []Check
+	cache    map[string]Result
+	cacheTTL time.Duration
+	timeout  time.Duration
+}
+
+// NewRegistry creates a registry; results are reused for cacheTTL
+func NewRegistry(cacheTTL, timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Registry{
+		cache:    make(map[string]Result),
+		cacheTTL: cacheTTL,
+		timeout:  timeout,
+	}
+}
+
+// Register adds a check; registering a name twice replaces the previous check
+func (r *Registry) Register(check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, c := range r.checks {
+		if c.Name == check.Name {
+			r.checks[i] = check
+			delete(r.cache, check.Name)
+			return
+		}
+	}
+	r.checks = append(r.checks, check)
+	sort.Slice(r.checks, func(i, j int) bool { return r.checks[i].Name < r.checks[j].Name })
+}
+
+// Liveness runs only the checks flagged as liveness checks
+func (r *Registry) Liveness(ctx context.Context) Report {
+	return r.run(ctx, true)
+}
+
+// Readiness runs every registered check
+func (r *Registry) Readiness(ctx context.Context) Report {
+	return r.run(ctx, false)
+}
+
+func (r *Registry) run(ctx context.Context, livenessOnly bool) Report {
+	r.mu.Lock()
+	checks := make([]Check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if !livenessOnly || c.Liveness {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.Unlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		now = time.Now()
+	)
+	for _, c := range checks {
+		if res, ok := r.cached(c.Name, now); ok {
+			report.Checks[c.Name] = res
+			continue
+		}
+
+		wg.Add(1)
+		go func(c Check) {
+			defer wg.Done()
+			res := r.execute(ctx, c)
+
+			mu.Lock()
+			report.Checks[c.Name] = res
+			mu.Unlock()
+
+			r.mu.Lock()
+			r.cache[c.Name] = res
+			r.mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		if res.Critical && res.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func (r *Registry) cached(name string, now time.Time) (Result, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res, ok := r.cache[name]
+	if !ok || r.cacheTTL <= 0 || now.Sub(res.CheckedAt) > r.cacheTTL {
+		return Result{}, false
+	}
+	return res, true
+}
+
+func (r *Registry) execute(ctx context.Context, c Check) Result {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = r.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Run(ctx)
+	res := Result{
+		Status:    StatusOK,
+		Critical:  c.Critical,
+		Duration:  time.Since(start).String(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// consoleMailer logs messages instead of delivering them, for development
+type consoleMailer struct {
+	from string
+	dir  string
+}
+
+// NewConsoleMailer creates a mailer that logs every message and, when dir is set, also writes it there as an .eml file
+func NewConsoleMailer(from, dir string) Mailer {
+	return &consoleMailer{from: from, dir: dir}
+}
+
+func (m *consoleMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("📧 mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	if m.dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), sanitize(msg.To))
+	return os.WriteFile(filepath.Join(m.dir, name), compose(m.from, msg, time.Now()), 0o644)
+}
+
+func (m *consoleMailer) Check(ctx context.Context) error {
+	if m.dir == "" {
+		return nil
+	}
+	return os.MkdirAll(m.dir, 0o755)
+}
+
+// sanitize keeps an address usable as part of a file name
+func sanitize(addr string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || r == '+' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, addr)
+}
@@ -0,0 +1,36 @@
+package mail
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/mail"
+
+	"github.com/vayura/config"
+)
+
+// New creates the mailer selected by cfg.Driver
+func New(cfg config.MailConfig) (Mailer, error) {
+	if _, err := envelopeAddress(cfg.From); err != nil {
+		return nil, err
+	}
+	switch cfg.Driver {
+	case config.MailDriverConsole, "":
+		return NewConsoleMailer(cfg.From, cfg.Dir), nil
+	case config.MailDriverSMTP:
+		return NewSMTPMailer(cfg), nil
+	}
+	return nil, fmt.Errorf("unsupported mail driver %q", cfg.Driver)
+}
+
+// envelopeAddress returns the bare address of a From header such as "Vayura <no-reply@vayura.id>"
+func envelopeAddress(from string) (string, error) {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return "", fmt.Errorf("invalid MAIL_FROM %q: %w", from, err)
+	}
+	return addr.Address, nil
+}
+
+func tlsConfig(host string) *tls.Config {
+	return &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+}
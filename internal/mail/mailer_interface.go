@@ -0,0 +1,18 @@
+// Package mail sends transactional email through a pluggable Mailer.
+package mail
+
+import "context"
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+	// Check reports whether the mailer can currently deliver messages
+	Check(ctx context.Context) error
+}
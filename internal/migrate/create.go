@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create writes an empty up/down migration pair into every dialect directory under root,
+// using the next version number free in all of them; it returns the created files
+func Create(root, name string) ([]string, error) {
+	slug := strings.Trim(nonWord.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		return nil, fmt.Errorf("invalid migration name %q", name)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	var next int64 = 1
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		existing, err := load(os.DirFS(dir))
+		if err != nil {
+			return nil, err
+		}
+		if n := len(existing); n > 0 && existing[n-1].Version >= next {
+			next = existing[n-1].Version + 1
+		}
+		dirs = append(dirs, dir)
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no dialect directories found in %s", root)
+	}
+
+	base := fmt.Sprintf("%04d_%s", next, slug)
+	var created []string
+	for _, dir := range dirs {
+		up := filepath.Join(dir, base+".up.sql")
+		down := filepath.Join(dir, base+".down.sql")
+		if err := os.WriteFile(up, []byte("-- "+name+"\n"), 0o644); err != nil {
+			return created, err
+		}
+		if err := os.WriteFile(down, []byte("-- revert "+name+"\n"), 0o644); err != nil {
+			return created, err
+		}
+		created = append(created, up, down)
+	}
+	return created, nil
+}
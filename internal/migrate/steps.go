@@ -0,0 +1,453 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"github.com/vayura/pkg/fieldcrypt"
+	"github.com/vayura/pkg/identity"
+	"github.com/vayura/pkg/phone"
+	"gorm.io/gorm"
+)
+
+// steps are the Go data migrations of the embedded migrations, by version
+var steps = map[int64]func(tx *gorm.DB) error{
+	3:  caseInsensitiveUniqueness,
+	4:  normalizeUserIdentity,
+	9:  normalizeUserPhones,
+	13: encryptUserPII,
+	16: chainAuditLog,
+	17: snapshotProfiles,
+	19: encryptVerificationPhones,
+}
+
+// downSteps are the Go data migrations run before the down SQL, by version
+var downSteps = map[int64]func(tx *gorm.DB) error{
+	13: decryptUserPII,
+	19: decryptVerificationPhones,
+}
+
+// Collision is a normalized email or username skeleton shared by several users
+type Collision struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+	IDs   []uint `json:"ids"`
+}
+
+// CollisionError reports the users that must be merged or renamed before normalization can be enforced
+type CollisionError struct {
+	Collisions []Collision
+}
+
+func (e *CollisionError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d identity collision(s) must be resolved first:", len(e.Collisions))
+	for _, c := range e.Collisions {
+		fmt.Fprintf(&b, "\n  %s %q is shared by users %v", c.Field, c.Value, c.IDs)
+	}
+	return b.String()
+}
+
+// caseRow is a user whose email or username differs from another one's only by letter case
+type caseRow struct {
+	ID    uint
+	Value string
+}
+
+// caseInsensitiveUniqueness adds the LOWER(email) and LOWER(username) unique indexes. It fails
+// with a CollisionError, before any index exists, when users differ only by letter case.
+func caseInsensitiveUniqueness(tx *gorm.DB) error {
+	var collisions []Collision
+	for _, column := range []string{"email", "username"} {
+		var rows []caseRow
+		query := fmt.Sprintf("SELECT id, LOWER(%[1]s) AS value FROM users WHERE LOWER(%[1]s) IN "+
+			"(SELECT LOWER(%[1]s) FROM users GROUP BY LOWER(%[1]s) HAVING COUNT(*) > 1) ORDER BY id", column)
+		if err := tx.Raw(query).Scan(&rows).Error; err != nil {
+			return err
+		}
+		groups := make(map[string][]uint)
+		for _, row := range rows {
+			groups[row.Value] = append(groups[row.Value], row.ID)
+		}
+		collisions = append(collisions, collisionsOf(column, groups)...)
+	}
+	if len(collisions) > 0 {
+		return &CollisionError{Collisions: collisions}
+	}
+
+	if err := tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS uni_users_email_lower ON users (LOWER(email))").Error; err != nil {
+		return err
+	}
+	return tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS uni_users_username_lower ON users (LOWER(username))").Error
+}
+
+// identityRow is the part of a users row read by normalizeUserIdentity
+type identityRow struct {
+	ID       uint
+	Email    string
+	Username string
+}
+
+// normalizeUserIdentity rewrites every email and username (soft-deleted rows included) to the
+// normalized form and fills username_skeleton. It fails with a CollisionError, leaving the data
+// untouched, when two users would end up with the same email or username skeleton.
+func normalizeUserIdentity(tx *gorm.DB) error {
+	var rows []identityRow
+	if err := tx.Raw("SELECT id, email, username FROM users ORDER BY id").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	emails := make(map[string][]uint)
+	skeletons := make(map[string][]uint)
+	normalized := make([]identityRow, len(rows))
+	for i, row := range rows {
+		email, err := identity.NormalizeEmail(row.Email)
+		if err != nil {
+			return fmt.Errorf("user %d: cannot normalize email %q: %w", row.ID, row.Email, err)
+		}
+		normalized[i] = identityRow{ID: row.ID, Email: email, Username: identity.NormalizeUsername(row.Username)}
+		emails[email] = append(emails[email], row.ID)
+		skeleton := identity.Skeleton(row.Username)
+		skeletons[skeleton] = append(skeletons[skeleton], row.ID)
+	}
+
+	collisions := append(collisionsOf("email", emails), collisionsOf("username", skeletons)...)
+	if len(collisions) > 0 {
+		return &CollisionError{Collisions: collisions}
+	}
+
+	for _, row := range normalized {
+		err := tx.Exec("UPDATE users SET email = ?, username = ?, username_skeleton = ? WHERE id = ?",
+			row.Email, row.Username, identity.Skeleton(row.Username), row.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collisionsOf returns the values of groups shared by more than one user, sorted by value
+func collisionsOf(field string, groups map[string][]uint) []Collision {
+	var list []Collision
+	for value, ids := range groups {
+		if len(ids) > 1 {
+			list = append(list, Collision{Field: field, Value: value, IDs: ids})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Value < list[j].Value })
+	return list
+}
+
+// phoneRow is the part of a users row read by normalizeUserPhones
+type phoneRow struct {
+	ID    uint
+	Phone string
+}
+
+// normalizeUserPhones rewrites stored phone numbers to E.164, reading national numbers in the
+// default region. Numbers that cannot be parsed are kept as they are and reported in the log;
+// they fail validation on the next profile update.
+func normalizeUserPhones(tx *gorm.DB) error {
+	var rows []phoneRow
+	if err := tx.Raw("SELECT id, phone FROM users WHERE phone IS NOT NULL AND phone <> '' ORDER BY id").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		normalized, err := phone.Normalize(row.Phone)
+		if err != nil {
+			log.Printf("⚠️  user %d: phone %q is not a valid number, left unchanged", row.ID, row.Phone)
+			continue
+		}
+		if normalized == row.Phone {
+			continue
+		}
+		if err := tx.Exec("UPDATE users SET phone = ? WHERE id = ?", normalized, row.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Domains of the encrypted users columns, as derived by the fieldcrypt serializer
+const (
+	phoneDomain    = "users.phone"
+	birthdayDomain = "users.birthday"
+)
+
+// plainPIIRow is the part of a users row read by encryptUserPII
+type plainPIIRow struct {
+	ID            uint
+	Phone         *string
+	BirthdayPlain *time.Time
+}
+
+// encryptUserPII encrypts phone and birthday_plain into phone and birthday with the active key
+// and fills the phone blind index. It needs the keyring only when there are values to encrypt.
+func encryptUserPII(tx *gorm.DB) error {
+	var rows []plainPIIRow
+	if err := tx.Raw("SELECT id, phone, birthday_plain FROM users ORDER BY id").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		var plainPhone string
+		if row.Phone != nil {
+			plainPhone = *row.Phone
+		}
+		var plainBirthday time.Time
+		if row.BirthdayPlain != nil {
+			plainBirthday = row.BirthdayPlain.UTC()
+		}
+
+		encPhone, err := fieldcrypt.EncryptValue(phoneDomain, plainPhone)
+		if err != nil {
+			return fmt.Errorf("user %d: %w", row.ID, err)
+		}
+		index, err := fieldcrypt.BlindIndex(phoneDomain, plainPhone)
+		if err != nil {
+			return fmt.Errorf("user %d: %w", row.ID, err)
+		}
+		encBirthday, err := fieldcrypt.EncryptValue(birthdayDomain, plainBirthday)
+		if err != nil {
+			return fmt.Errorf("user %d: %w", row.ID, err)
+		}
+		err = tx.Exec("UPDATE users SET phone = ?, phone_index = ?, birthday = ? WHERE id = ?", encPhone, index, encBirthday, row.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encryptedPIIRow is the part of a users row read by decryptUserPII
+type encryptedPIIRow struct {
+	ID       uint
+	Phone    *string
+	Birthday *string
+}
+
+// decryptUserPII reverses encryptUserPII: phone is decrypted in place and birthday into birthday_plain
+func decryptUserPII(tx *gorm.DB) error {
+	var rows []encryptedPIIRow
+	if err := tx.Raw("SELECT id, phone, birthday FROM users ORDER BY id").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		var plainPhone string
+		if row.Phone != nil && *row.Phone != "" {
+			b, err := fieldcrypt.Decrypt(phoneDomain, *row.Phone)
+			if err != nil {
+				return fmt.Errorf("user %d: %w", row.ID, err)
+			}
+			if err := json.Unmarshal(b, &plainPhone); err != nil {
+				return fmt.Errorf("user %d: %w", row.ID, err)
+			}
+		}
+		var plainBirthday *time.Time
+		if row.Birthday != nil && *row.Birthday != "" {
+			b, err := fieldcrypt.Decrypt(birthdayDomain, *row.Birthday)
+			if err != nil {
+				return fmt.Errorf("user %d: %w", row.ID, err)
+			}
+			var t time.Time
+			if err := json.Unmarshal(b, &t); err != nil {
+				return fmt.Errorf("user %d: %w", row.ID, err)
+			}
+			plainBirthday = &t
+		}
+		err := tx.Exec("UPDATE users SET phone = ?, birthday_plain = ? WHERE id = ?", plainPhone, plainBirthday, row.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verificationPhoneDomain is the domain of the encrypted phone_verifications.phone column
+const verificationPhoneDomain = "phone_verifications.phone"
+
+// verificationPhoneRow is the part of a phone_verifications row read by the phone steps
+type verificationPhoneRow struct {
+	ID    uint
+	Phone string
+}
+
+// encryptVerificationPhones encrypts the phone numbers codes were sent to with the active key
+func encryptVerificationPhones(tx *gorm.DB) error {
+	var rows []verificationPhoneRow
+	if err := tx.Raw("SELECT id, phone FROM phone_verifications ORDER BY id").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		encPhone, err := fieldcrypt.EncryptValue(verificationPhoneDomain, row.Phone)
+		if err != nil {
+			return fmt.Errorf("phone verification %d: %w", row.ID, err)
+		}
+		if err := tx.Exec("UPDATE phone_verifications SET phone = ? WHERE id = ?", encPhone, row.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decryptVerificationPhones reverses encryptVerificationPhones
+func decryptVerificationPhones(tx *gorm.DB) error {
+	var rows []verificationPhoneRow
+	if err := tx.Raw("SELECT id, phone FROM phone_verifications ORDER BY id").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		var plainPhone string
+		if row.Phone != "" {
+			b, err := fieldcrypt.Decrypt(verificationPhoneDomain, row.Phone)
+			if err != nil {
+				return fmt.Errorf("phone verification %d: %w", row.ID, err)
+			}
+			if err := json.Unmarshal(b, &plainPhone); err != nil {
+				return fmt.Errorf("phone verification %d: %w", row.ID, err)
+			}
+		}
+		if err := tx.Exec("UPDATE phone_verifications SET phone = ? WHERE id = ?", plainPhone, row.ID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditChangesDomain is the domain of the encrypted audit_entries.changes column
+const auditChangesDomain = "audit_entries.changes"
+
+// auditRow is an audit_entries row as read by chainAuditLog
+type auditRow struct {
+	ID        uint
+	CreatedAt time.Time
+	ActorID   *uint
+	Source    string
+	TargetID  *uint
+	Action    string
+	Changes   string
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+// chainAuditLog links the existing audit entries into a hash chain in ID order and points the
+// chain head at the last one
+func chainAuditLog(tx *gorm.DB) error {
+	var (
+		lastID   uint
+		lastHash string
+	)
+	for {
+		var rows []auditRow
+		err := tx.Raw("SELECT id, created_at, actor_id, source, target_id, action, changes, ip, user_agent, request_id FROM audit_entries WHERE id > ? ORDER BY id LIMIT 1000", lastID).Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		for _, row := range rows {
+			entry := models.AuditEntry{
+				ID:        row.ID,
+				CreatedAt: row.CreatedAt,
+				ActorID:   row.ActorID,
+				Source:    row.Source,
+				TargetID:  row.TargetID,
+				Action:    row.Action,
+				IP:        row.IP,
+				UserAgent: row.UserAgent,
+				RequestID: row.RequestID,
+			}
+			if row.Changes != "" {
+				b, err := fieldcrypt.Decrypt(auditChangesDomain, row.Changes)
+				if err != nil {
+					return fmt.Errorf("audit entry %d: %w", row.ID, err)
+				}
+				if err := json.Unmarshal(b, &entry.Changes); err != nil {
+					return fmt.Errorf("audit entry %d: %w", row.ID, err)
+				}
+			}
+			entry.Chain(lastHash)
+
+			err := tx.Exec("UPDATE audit_entries SET prev_hash = ?, changes_hash = ?, client_hash = ?, hash = ? WHERE id = ?",
+				entry.PrevHash, entry.ChangesHash, entry.ClientHash, entry.Hash, entry.ID).Error
+			if err != nil {
+				return err
+			}
+			lastID, lastHash = entry.ID, entry.Hash
+		}
+	}
+	return tx.Exec("UPDATE audit_chain SET last_id = ?, last_hash = ? WHERE id = 1", lastID, lastHash).Error
+}
+
+// profileRow is the part of a users row read by snapshotProfiles
+type profileRow struct {
+	ID               uint
+	UpdatedAt        time.Time
+	FullName         string
+	Username         string
+	UsernameSkeleton string
+	Email            string
+	Phone            *string
+	Avatar           *string
+	Gender           *string
+	Birthday         *string
+}
+
+// snapshotProfiles adds the current profile of every user, soft-deleted ones included, as its
+// first version, valid from the last update. Phone and birthday are re-encrypted for the new table.
+func snapshotProfiles(tx *gorm.DB) error {
+	var rows []profileRow
+	err := tx.Raw("SELECT id, updated_at, full_name, username, username_skeleton, email, phone, avatar, gender, birthday FROM users ORDER BY id").Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		encPhone, err := reencryptColumn(phoneDomain, "profile_versions.phone", row.Phone)
+		if err != nil {
+			return fmt.Errorf("user %d: %w", row.ID, err)
+		}
+		encBirthday, err := reencryptColumn(birthdayDomain, "profile_versions.birthday", row.Birthday)
+		if err != nil {
+			return fmt.Errorf("user %d: %w", row.ID, err)
+		}
+		err = tx.Exec(`INSERT INTO profile_versions (user_id, created_at, source, full_name, username, username_skeleton, email, phone, avatar, gender, birthday)
+			VALUES (?, ?, 'system', ?, ?, ?, ?, ?, ?, ?, ?)`,
+			row.ID, row.UpdatedAt, row.FullName, row.Username, row.UsernameSkeleton, row.Email, encPhone, deref(row.Avatar), deref(row.Gender), encBirthday).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reencryptColumn moves a value encrypted for one column to another; empty values stay empty
+func reencryptColumn(from, to string, value *string) (string, error) {
+	if value == nil || *value == "" {
+		return "", nil
+	}
+	plaintext, err := fieldcrypt.Decrypt(from, *value)
+	if err != nil {
+		return "", err
+	}
+	return fieldcrypt.Encrypt(to, plaintext)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,196 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Audited actions
+const (
+	AuditRegistered     = "user.registered"
+	AuditCreated        = "user.created" // by an operator
+	AuditLogin          = "auth.login"
+	AuditLoginFailed    = "auth.login_failed"
+	AuditProfileUpdated = "user.profile_updated"
+	AuditAvatarUpdated  = "user.avatar_updated"
+	AuditEmailChanged   = "user.email_changed"
+	AuditPhoneVerified  = "user.phone_verified"
+	AuditRoleChanged    = "user.role_changed"
+	AuditPasswordReset  = "user.password_reset"
+	AuditSuspended      = "user.suspended"
+	AuditUnsuspended    = "user.unsuspended"
+	AuditDeleted        = "user.deleted"
+	AuditRestored       = "user.restored"
+	AuditPurged         = "user.purged"
+
+	AuditUsernameGranted = "user.username_granted"
+	AuditUsernameRevoked = "user.username_grant_revoked"
+)
+
+// AuditRedacted replaces values that are never recorded, like password hashes
+const AuditRedacted = "[redacted]"
+
+// AuditEntry records who did what to which user. Entries are only appended; the diff is
+// encrypted like other personal data, and purging a user strips the entries' personal data.
+// Entries form a hash chain: Hash covers the entry and PrevHash, the Hash of the entry before.
+type AuditEntry struct {
+	ID        uint         `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time    `json:"created_at"`
+	ActorID   *uint        `json:"actor_id,omitempty"`
+	Source    string       `json:"source" gorm:"not null"` // api, cli or system
+	TargetID  *uint        `json:"target_id,omitempty"`
+	Action    string       `json:"action" gorm:"not null"`
+	Changes   AuditChanges `json:"changes,omitempty" gorm:"serializer:encrypted"`
+	IP        string       `json:"ip,omitempty"`
+	UserAgent string       `json:"user_agent,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+
+	PrevHash    string `json:"prev_hash"`
+	ChangesHash string `json:"changes_hash"` // of Changes
+	ClientHash  string `json:"client_hash"`  // of IP and UserAgent
+	Hash        string `json:"hash"`
+}
+
+// AuditCheckpoint is a signed statement that the chain ended with Hash at entry EntryID
+type AuditCheckpoint struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	EntryID   uint      `json:"entry_id" gorm:"not null"`
+	Hash      string    `json:"hash" gorm:"not null"`
+	KeyID     string    `json:"key_id"` // signing key; empty for JWT_SECRET
+	Signature string    `json:"signature" gorm:"not null"`
+}
+
+// Payload returns the bytes covered by the checkpoint signature
+func (c *AuditCheckpoint) Payload() []byte {
+	return []byte(fmt.Sprintf("vayura-audit-checkpoint/v1\n%d\n%s\n%s", c.EntryID, c.Hash, c.CreatedAt.UTC().Format(time.RFC3339Nano)))
+}
+
+// auditHashInput is the content of an entry covered by its hash
+type auditHashInput struct {
+	Version     int    `json:"v"`
+	PrevHash    string `json:"prev_hash"`
+	CreatedAt   string `json:"created_at"`
+	ActorID     *uint  `json:"actor_id"`
+	Source      string `json:"source"`
+	TargetID    *uint  `json:"target_id"`
+	Action      string `json:"action"`
+	RequestID   string `json:"request_id"`
+	ChangesHash string `json:"changes_hash"`
+	ClientHash  string `json:"client_hash"`
+}
+
+// auditDigest returns the hex SHA-256 of the JSON encoding of v
+func auditDigest(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// Diff values come from JSON-friendly user fields; fail closed with a digest nothing matches
+		return "unhashable: " + err.Error()
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (e *AuditEntry) changesDigest() string {
+	return auditDigest(e.Changes)
+}
+
+func (e *AuditEntry) clientDigest() string {
+	return auditDigest([]string{e.IP, e.UserAgent})
+}
+
+func (e *AuditEntry) chainDigest() string {
+	return auditDigest(auditHashInput{
+		Version:     1,
+		PrevHash:    e.PrevHash,
+		CreatedAt:   e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		ActorID:     e.ActorID,
+		Source:      e.Source,
+		TargetID:    e.TargetID,
+		Action:      e.Action,
+		RequestID:   e.RequestID,
+		ChangesHash: e.ChangesHash,
+		ClientHash:  e.ClientHash,
+	})
+}
+
+// Chain links the entry after the entry with hash prev, the empty string for the first entry, and
+// fills its hashes. CreatedAt must already be at the precision the database stores.
+func (e *AuditEntry) Chain(prev string) {
+	e.PrevHash = prev
+	e.ChangesHash = e.changesDigest()
+	e.ClientHash = e.clientDigest()
+	e.Hash = e.chainDigest()
+}
+
+// VerifyHash reports whether the entry still matches its hash, and whether its diff or client
+// details were cleared since it was hashed. Purging a user clears them, so the caller accepts a
+// cleared field only for an entry about (or, for client details, by) a purged user.
+func (e *AuditEntry) VerifyHash() (ok, changesCleared, clientCleared bool) {
+	if e.changesDigest() != e.ChangesHash {
+		if e.Changes != nil {
+			return false, false, false
+		}
+		changesCleared = true
+	}
+	if e.clientDigest() != e.ClientHash {
+		if e.IP != "" || e.UserAgent != "" {
+			return false, false, false
+		}
+		clientCleared = true
+	}
+	return e.chainDigest() == e.Hash, changesCleared, clientCleared
+}
+
+// AuditChange is the value of a field before and after an action
+type AuditChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// AuditChanges maps field names to their change
+type AuditChanges map[string]AuditChange
+
+// AuditFields returns the fields of u recorded in audit diffs; the password hash is never recorded
+func (u *User) AuditFields() map[string]interface{} {
+	fields := map[string]interface{}{
+		"full_name":         u.FullName,
+		"username":          u.Username,
+		"email":             u.Email,
+		"phone":             u.Phone,
+		"phone_verified_at": u.PhoneVerifiedAt,
+		"avatar":            u.Avatar,
+		"gender":            u.Gender,
+		"birthday":          "",
+		"role":              u.Role,
+		"suspended_at":      u.SuspendedAt,
+	}
+	if !u.Birthday.IsZero() {
+		fields["birthday"] = u.Birthday.Format("2006-01-02")
+	}
+	return fields
+}
+
+// DiffUsers returns the audited fields that differ between before and after; a changed password
+// shows up with both values redacted. A nil before diffs against an empty user.
+func DiffUsers(before, after *User) AuditChanges {
+	if before == nil {
+		before = &User{}
+	}
+	old, current := before.AuditFields(), after.AuditFields()
+
+	changes := AuditChanges{}
+	for field, value := range current {
+		if !reflect.DeepEqual(old[field], value) {
+			changes[field] = AuditChange{Old: old[field], New: value}
+		}
+	}
+	if before.Password != after.Password {
+		changes["password"] = AuditChange{Old: AuditRedacted, New: AuditRedacted}
+	}
+	return changes
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Data export states
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+	ExportExpired = "expired"
+)
+
+// DataExport is a request for a copy of everything stored about a user. A background job writes
+// the ZIP to File and emails a download link valid until ExpiresAt; only the token hash is stored.
+type DataExport struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"not null"`
+	TokenHash   *string    `json:"-" gorm:"unique"`
+	File        string     `json:"-"`
+	Size        int64      `json:"size,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// IsDownloadable reports whether the export file can be downloaded at now
+func (e *DataExport) IsDownloadable(now time.Time) bool {
+	return e.Status == ExportReady && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// EmailChange is a request to move an account to NewEmail. The swap happens when the link sent
+// to NewEmail is confirmed; the link sent to OldEmail cancels it. Only token hashes are stored.
+type EmailChange struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	UserID           uint       `json:"user_id" gorm:"not null;index"`
+	OldEmail         string     `json:"old_email" gorm:"not null"`
+	NewEmail         string     `json:"new_email" gorm:"not null"`
+	ConfirmTokenHash string     `json:"-" gorm:"not null;unique"`
+	CancelTokenHash  string     `json:"-" gorm:"not null;unique"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	ConfirmedAt      *time.Time `json:"confirmed_at,omitempty"`
+	CancelledAt      *time.Time `json:"cancelled_at,omitempty"`
+}
+
+// IsPending reports whether the change can still be confirmed or cancelled at now
+func (e *EmailChange) IsPending(now time.Time) bool {
+	return e.ConfirmedAt == nil && e.CancelledAt == nil && now.Before(e.ExpiresAt)
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// PhoneVerification is a one-time code sent by SMS to prove ownership of Phone. Phone is stored
+// encrypted and only a hash of the code is kept; it stops being accepted once used, expired or
+// guessed wrong too often.
+type PhoneVerification struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Phone      string     `json:"phone" gorm:"not null;serializer:encrypted"`
+	CodeHash   string     `json:"-" gorm:"not null"`
+	Attempts   int        `json:"attempts" gorm:"not null;default:0"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// IsPending reports whether the code can still be checked at now
+func (v *PhoneVerification) IsPending(now time.Time) bool {
+	return v.VerifiedAt == nil && now.Before(v.ExpiresAt)
+}
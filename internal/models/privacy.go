@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Visibility levels of a profile field
+const (
+	VisibilityPublic   = "public"   // anyone, including anonymous visitors
+	VisibilityContacts = "contacts" // users the owner added as contacts
+	VisibilityPrivate  = "private"  // only the owner
+)
+
+// IsValidVisibility reports whether v is a known visibility level
+func IsValidVisibility(v string) bool {
+	return v == VisibilityPublic || v == VisibilityContacts || v == VisibilityPrivate
+}
+
+// DefaultVisibility is the visibility of every field a user can hide, until they change it.
+// Username is always public since public profiles are looked up by it.
+var DefaultVisibility = map[string]string{
+	"full_name": VisibilityPublic,
+	"avatar":    VisibilityPublic,
+	"gender":    VisibilityPrivate,
+	"birthday":  VisibilityPrivate,
+	"email":     VisibilityPrivate,
+	"phone":     VisibilityPrivate,
+}
+
+// FieldVisibility is the visibility a user chose for one profile field
+type FieldVisibility struct {
+	UserID     uint   `gorm:"primaryKey"`
+	Field      string `gorm:"primaryKey"`
+	Visibility string `gorm:"not null"`
+}
+
+// TableName keeps the table name readable
+func (FieldVisibility) TableName() string {
+	return "profile_visibility"
+}
+
+// Kinds of relation a user can have with another user
+const (
+	RelationContact = "contact"
+	RelationBlock   = "block"
+)
+
+// Relation is a one-way relation from UserID to OtherID; a user has at most one relation with
+// another user, so blocking a contact removes them from the contacts
+type Relation struct {
+	UserID    uint      `json:"-" gorm:"primaryKey"`
+	OtherID   uint      `json:"-" gorm:"primaryKey"`
+	Kind      string    `json:"kind" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName keeps the table name readable
+func (Relation) TableName() string {
+	return "user_relations"
+}
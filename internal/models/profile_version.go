@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/vayura/pkg/identity"
+)
+
+// ProfileVersion is a snapshot of a user's profile fields, valid from CreatedAt until ValidTo (nil
+// for the current version). A version is added for every change of the profile, in the same
+// transaction; purging the user clears the fields and keeps only the username skeleton and the
+// times, which the username reuse cooldown needs.
+type ProfileVersion struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	UserID           uint       `json:"user_id" gorm:"not null"`
+	CreatedAt        time.Time  `json:"valid_from"`
+	ValidTo          *time.Time `json:"valid_to,omitempty"`
+	ActorID          *uint      `json:"actor_id,omitempty"`
+	Source           string     `json:"source" gorm:"not null"` // api, cli or system
+	FullName         string     `json:"full_name"`
+	Username         string     `json:"username"`
+	UsernameSkeleton string     `json:"-" gorm:"not null"`
+	Email            string     `json:"email"`
+	Phone            string     `json:"phone" gorm:"serializer:encrypted"`
+	Avatar           string     `json:"avatar"`
+	Gender           string     `json:"gender"`
+	Birthday         time.Time  `json:"birthday" gorm:"serializer:encrypted"`
+}
+
+// ProfileVersionOf returns a version holding the current profile fields of u
+func ProfileVersionOf(u *User) *ProfileVersion {
+	return &ProfileVersion{
+		UserID:           u.ID,
+		FullName:         u.FullName,
+		Username:         u.Username,
+		UsernameSkeleton: identity.Skeleton(u.Username),
+		Email:            u.Email,
+		Phone:            u.Phone,
+		Avatar:           u.Avatar,
+		Gender:           u.Gender,
+		Birthday:         u.Birthday,
+	}
+}
+
+// SameProfile reports whether v and other hold the same profile fields
+func (v *ProfileVersion) SameProfile(other *ProfileVersion) bool {
+	return v.FullName == other.FullName &&
+		v.Username == other.Username &&
+		v.Email == other.Email &&
+		v.Phone == other.Phone &&
+		v.Avatar == other.Avatar &&
+		v.Gender == other.Gender &&
+		v.Birthday.Equal(other.Birthday)
+}
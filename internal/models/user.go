@@ -3,29 +3,82 @@ package models
 import (
 	"time"
 
+	"github.com/vayura/pkg/fieldcrypt"
+	"github.com/vayura/pkg/identity"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// Known user roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	FullName  string         `json:"full_name" gorm:"not null"`
-	Username  string         `json:"username" gorm:"unique;not null"`
-	Email     string         `json:"email" gorm:"unique;not null"`
-	Phone     string         `json:"phone"`
-	Avatar    string         `json:"avatar"`
-	Gender    string         `json:"gender"`
-	Birthday  time.Time      `json:"birthday"`
-	Role      string         `json:"role" gorm:"default:user"`
-	Password  string         `json:"-" gorm:"not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	FullName         string         `json:"full_name" gorm:"not null"`
+	Username         string         `json:"username" gorm:"unique;not null"`
+	UsernameSkeleton string         `json:"-" gorm:"not null"`
+	Email            string         `json:"email" gorm:"unique;not null"`
+	Phone            string         `json:"phone" gorm:"serializer:encrypted"` // E.164
+	PhoneIndex       string         `json:"-" gorm:"not null;default:''"`      // blind index of Phone
+	PhoneVerifiedAt  *time.Time     `json:"phone_verified_at,omitempty"`
+	Avatar           string         `json:"avatar"`
+	Gender           string         `json:"gender"`
+	Birthday         time.Time      `json:"birthday" gorm:"serializer:encrypted"`
+	Role             string         `json:"role" gorm:"default:user"`
+	Password         string         `json:"-" gorm:"not null"`
+	SuspendedAt      *time.Time     `json:"suspended_at,omitempty"`
+	Version          int64          `json:"-" gorm:"not null;default:1"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// IsValidRole reports whether role is a known user role
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
+
+// IsSuspended reports whether the account has been suspended by an operator
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// NormalizeIdentity normalizes Email and Username and derives UsernameSkeleton, the look-alike
+// form of Username that is unique across all accounts; repositories call it before every write
+func (u *User) NormalizeIdentity() error {
+	email, err := identity.NormalizeEmail(u.Email)
+	if err != nil {
+		return err
+	}
+	u.Email = email
+	u.Username = identity.NormalizeUsername(u.Username)
+	u.UsernameSkeleton = identity.Skeleton(u.Username)
+	return nil
+}
+
+// PhoneIndexDomain is the blind index domain of User.Phone
+const PhoneIndexDomain = "users.phone"
+
+// DeriveIndexes fills PhoneIndex, the blind index of the encrypted Phone; repositories call it
+// before writing Phone
+func (u *User) DeriveIndexes() error {
+	index, err := fieldcrypt.BlindIndex(PhoneIndexDomain, u.Phone)
+	if err != nil {
+		return err
+	}
+	u.PhoneIndex = index
+	return nil
 }
 
+// PasswordCost is the bcrypt cost of password hashes; tests lower it to run fast
+var PasswordCost = 14
+
 // HashPassword digunakan sebelum simpan ke DB
 func (u *User) HashPassword(password string) error {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost)
 	if err != nil {
 		return err
 	}
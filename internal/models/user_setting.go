@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// UserSetting is a user's override of one registered setting; Value holds its JSON encoding
+type UserSetting struct {
+	UserID    uint      `gorm:"primaryKey"`
+	Key       string    `gorm:"primaryKey"`
+	Value     string    `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
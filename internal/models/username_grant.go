@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// UsernameGrant allows a user to take a reserved username, or a look-alike of it; grants are
+// made by admins and removed with the user
+type UsernameGrant struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt        time.Time `json:"created_at"`
+	Username         string    `json:"username" gorm:"not null"`
+	UsernameSkeleton string    `json:"-" gorm:"not null"`
+	UserID           uint      `json:"user_id" gorm:"not null"`
+	GrantedBy        *uint     `json:"granted_by,omitempty"`
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vayura/internal/models"
+)
+
+// AuditRepository defines the interface for the audit log. Entries are appended and never edited;
+// they only lose personal data when a user is purged and leave the table when archived.
+type AuditRepository interface {
+	UserAnonymizer
+	// Create appends entry to the hash chain, setting its hashes
+	Create(ctx context.Context, entry *models.AuditEntry) error
+	// List returns the entries matching filter, newest first
+	List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error)
+	// ListAfter returns up to limit entries with an ID above afterID, oldest first
+	ListAfter(ctx context.Context, afterID uint, limit int) ([]models.AuditEntry, error)
+	// DeleteThrough removes the entries with an ID up to id and returns how many were removed
+	DeleteThrough(ctx context.Context, id uint) (int64, error)
+	// ChainHead returns the ID and hash of the last entry appended, archived or not
+	ChainHead(ctx context.Context) (lastID uint, lastHash string, err error)
+	// Purged reports whether the user was purged: an entry records the purge, or the user row,
+	// soft-deleted or not, is gone
+	Purged(ctx context.Context, userID uint) (bool, error)
+	CreateCheckpoint(ctx context.Context, checkpoint *models.AuditCheckpoint) error
+	// ListCheckpoints returns every checkpoint, oldest first
+	ListCheckpoints(ctx context.Context) ([]models.AuditCheckpoint, error)
+}
+
+// AuditFilter filters and paginates audit entries; zero fields match everything
+type AuditFilter struct {
+	ActorID   uint
+	TargetID  uint
+	UserID    uint // entries whose actor or target is the user
+	Action    string
+	RequestID string
+	From      time.Time // created at or after
+	To        time.Time // created before
+	BeforeID  uint      // only entries older than this one, for paging
+	Limit     int
+}
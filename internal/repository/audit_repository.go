@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"gorm.io/gorm"
+)
+
+// auditRepository implements AuditRepository interface
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// auditChainHead is the audit_chain row
+type auditChainHead struct {
+	LastID   uint
+	LastHash string
+}
+
+// Create appends entry to the chain. Touching the chain head first takes its row lock (the write
+// lock in SQLite), so concurrent appends queue up and the chain follows the ID order.
+func (r *auditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("UPDATE audit_chain SET last_id = last_id WHERE id = 1").Error; err != nil {
+			return err
+		}
+		var head auditChainHead
+		if err := tx.Raw("SELECT last_id, last_hash FROM audit_chain WHERE id = 1").Scan(&head).Error; err != nil {
+			return err
+		}
+
+		// Postgres keeps microseconds; the hash must cover the time as it reads back
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = time.Now()
+		}
+		entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+		entry.Chain(head.LastHash)
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE audit_chain SET last_id = ?, last_hash = ? WHERE id = 1", entry.ID, entry.Hash).Error
+	})
+	return translateError(err, nil)
+}
+
+func (r *auditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error) {
+	query := conn(ctx, r.db).Model(&models.AuditEntry{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("actor_id = ? OR target_id = ?", filter.UserID, filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.BeforeID != 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var entries []models.AuditEntry
+	err := query.Order("id DESC").Find(&entries).Error
+	return entries, translateError(err, nil)
+}
+
+func (r *auditRepository) ListAfter(ctx context.Context, afterID uint, limit int) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	err := conn(ctx, r.db).Where("id > ?", afterID).Order("id").Limit(limit).Find(&entries).Error
+	return entries, translateError(err, nil)
+}
+
+func (r *auditRepository) DeleteThrough(ctx context.Context, id uint) (int64, error) {
+	res := conn(ctx, r.db).Where("id <= ?", id).Delete(&models.AuditEntry{})
+	return res.RowsAffected, translateError(res.Error, nil)
+}
+
+func (r *auditRepository) ChainHead(ctx context.Context) (uint, string, error) {
+	var head auditChainHead
+	err := conn(ctx, r.db).Raw("SELECT last_id, last_hash FROM audit_chain WHERE id = 1").Scan(&head).Error
+	return head.LastID, head.LastHash, translateError(err, nil)
+}
+
+func (r *auditRepository) Purged(ctx context.Context, userID uint) (bool, error) {
+	db := conn(ctx, r.db)
+	var count int64
+	err := db.Model(&models.AuditEntry{}).Where("target_id = ? AND action = ?", userID, models.AuditPurged).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, translateError(err, nil)
+	}
+	// the purge entry may have been archived
+	err = db.Unscoped().Model(&models.User{}).Where("id = ?", userID).Count(&count).Error
+	return count == 0, translateError(err, nil)
+}
+
+func (r *auditRepository) CreateCheckpoint(ctx context.Context, checkpoint *models.AuditCheckpoint) error {
+	return translateError(conn(ctx, r.db).Create(checkpoint).Error, nil)
+}
+
+func (r *auditRepository) ListCheckpoints(ctx context.Context) ([]models.AuditCheckpoint, error) {
+	var checkpoints []models.AuditCheckpoint
+	err := conn(ctx, r.db).Order("id").Find(&checkpoints).Error
+	return checkpoints, translateError(err, nil)
+}
+
+// AnonymizeUser drops the diffs of entries about the user, which hold their personal data, and
+// the client details of entries about or by the user; the ids, actions and hashes stay
+func (r *auditRepository) AnonymizeUser(ctx context.Context, userID uint) error {
+	db := conn(ctx, r.db)
+	err := db.Model(&models.AuditEntry{}).Where("target_id = ?", userID).
+		Updates(map[string]interface{}{"changes": "", "ip": "", "user_agent": ""}).Error
+	if err != nil {
+		return translateError(err, nil)
+	}
+	err = db.Model(&models.AuditEntry{}).Where("actor_id = ?", userID).
+		Updates(map[string]interface{}{"ip": "", "user_agent": ""}).Error
+	return translateError(err, nil)
+}
@@ -2,9 +2,13 @@ package repository
 
 import (
 	"context"
-	"errors"
+	"strings"
+	"time"
 
 	"github.com/vayura/internal/models"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/fieldcrypt"
+	"github.com/vayura/pkg/identity"
 	"gorm.io/gorm"
 )
 
@@ -18,64 +22,204 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+// conn returns the connection for ctx, joining the transaction started by TxManager if any
+func (r *userRepository) conn(ctx context.Context) *gorm.DB {
+	return conn(ctx, r.db)
+}
+
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	if err := user.NormalizeIdentity(); err != nil {
+		return err
+	}
+	if err := user.DeriveIndexes(); err != nil {
+		return err
+	}
+	return translateError(r.conn(ctx).Create(user).Error, nil)
 }
 
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	email, err := identity.NormalizeEmail(email)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
 	var user models.User
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err = r.conn(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
+		return nil, translateError(err, ErrUserNotFound)
 	}
 	return &user, nil
 }
 
 func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	err := r.db.WithContext(ctx).First(&user, id).Error
+	err := r.conn(ctx).First(&user, id).Error
+	if err != nil {
+		return nil, translateError(err, ErrUserNotFound)
+	}
+	return &user, nil
+}
+
+// FindByPhone looks the E.164 number up through its blind index, since phone is encrypted
+func (r *userRepository) FindByPhone(ctx context.Context, phone string) (*models.User, error) {
+	if phone == "" {
+		return nil, ErrUserNotFound
+	}
+	index, err := fieldcrypt.BlindIndex(models.PhoneIndexDomain, phone)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
 		return nil, err
 	}
+	var user models.User
+	err = r.conn(ctx).Where("phone_index = ?", index).Order("id").First(&user).Error
+	if err != nil {
+		return nil, translateError(err, ErrUserNotFound)
+	}
 	return &user, nil
 }
 
+// FindByUsername matches case-insensitively; the skeleton narrows the lookup to at most one row
 func (r *userRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	username = identity.NormalizeUsername(username)
 	var user models.User
-	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
+	err := r.conn(ctx).Where("username_skeleton = ?", identity.Skeleton(username)).First(&user).Error
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
+		return nil, translateError(err, ErrUserNotFound)
+	}
+	if !strings.EqualFold(user.Username, username) {
+		return nil, ErrUserNotFound
 	}
 	return &user, nil
 }
 
-func (r *userRepository) Update(ctx context.Context, user *models.User) error {
-	return r.db.WithContext(ctx).Save(user).Error
+func (r *userRepository) Update(ctx context.Context, user *models.User, columns ...string) error {
+	if err := user.NormalizeIdentity(); err != nil {
+		return err
+	}
+	if err := user.DeriveIndexes(); err != nil {
+		return err
+	}
+
+	version := user.Version
+	user.Version++
+	user.UpdatedAt = time.Now()
+	q := r.conn(ctx).Model(user).Where("version = ?", version)
+	if len(columns) == 0 {
+		q = q.Select("*").Omit("id", "created_at", "deleted_at")
+	} else {
+		q = q.Select(updatedColumns(columns))
+	}
+
+	res := q.Updates(user)
+	if res.Error == nil && res.RowsAffected == 0 {
+		if _, err := r.FindByID(ctx, user.ID); err != nil {
+			res.Error = err
+		} else {
+			res.Error = pkg.ErrVersionMismatch
+		}
+	}
+	if res.Error != nil {
+		user.Version = version
+		return translateError(res.Error, nil)
+	}
+	return nil
 }
 
 func (r *userRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&models.User{}, id).Error
+	return translateError(r.conn(ctx).Delete(&models.User{}, id).Error, nil)
+}
+
+func (r *userRepository) FindDeletedByEmail(ctx context.Context, email string) (*models.User, error) {
+	email, err := identity.NormalizeEmail(email)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	var user models.User
+	err = r.conn(ctx).Unscoped().Where("email = ? AND deleted_at IS NOT NULL", email).First(&user).Error
+	if err != nil {
+		return nil, translateError(err, ErrUserNotFound)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Restore(ctx context.Context, user *models.User) error {
+	now := time.Now()
+	res := r.conn(ctx).Unscoped().Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", user.ID).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1"), "updated_at": now})
+	if res.Error != nil {
+		return translateError(res.Error, nil)
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+	user.Version++
+	user.UpdatedAt = now
+	return nil
 }
 
 func (r *userRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	email, err := identity.NormalizeEmail(email)
+	if err != nil {
+		return false, nil
+	}
 	var count int64
-	err := r.db.WithContext(ctx).Model(&models.User{}).Where("email = ?", email).Count(&count).Error
-	return count > 0, err
+	err = r.conn(ctx).Model(&models.User{}).Where("email = ?", email).Count(&count).Error
+	return count > 0, translateError(err, nil)
 }
 
+// UsernameExists also reports look-alikes of username, such as "paypa1" for "paypal"
 func (r *userRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(&models.User{}).Where("username = ?", username).Count(&count).Error
-	return count > 0, err
+	err := r.conn(ctx).Model(&models.User{}).Where("username_skeleton = ?", identity.Skeleton(username)).Count(&count).Error
+	return count > 0, translateError(err, nil)
+}
+
+func (r *userRepository) List(ctx context.Context, opts ListOptions) ([]models.User, error) {
+	q := r.conn(ctx).Model(&models.User{})
+	switch {
+	case opts.OnlyDeleted:
+		q = q.Unscoped().Where("deleted_at IS NOT NULL")
+		if !opts.DeletedBefore.IsZero() {
+			q = q.Where("deleted_at < ?", opts.DeletedBefore)
+		}
+	case opts.IncludeDeleted:
+		q = q.Unscoped()
+	}
+	if opts.Role != "" {
+		q = q.Where("role = ?", opts.Role)
+	}
+	if opts.Search != "" {
+		like := "%" + strings.ToLower(opts.Search) + "%"
+		q = q.Where("LOWER(username) LIKE ? OR LOWER(email) LIKE ? OR LOWER(full_name) LIKE ?", like, like, like)
+	}
+	if opts.Limit > 0 {
+		q = q.Limit(opts.Limit)
+	}
+	if opts.Offset > 0 {
+		q = q.Offset(opts.Offset)
+	}
+
+	var users []models.User
+	err := q.Order("id").Find(&users).Error
+	return users, translateError(err, nil)
+}
+
+func (r *userRepository) HardDelete(ctx context.Context, id uint) error {
+	return translateError(r.conn(ctx).Unscoped().Delete(&models.User{}, id).Error, nil)
+}
+
+// updatedColumns returns columns plus the columns every update writes or derives from them
+func updatedColumns(columns []string) []string {
+	list := append([]string{"version", "updated_at"}, columns...)
+	for _, c := range columns {
+		switch c {
+		case "username":
+			list = append(list, "username_skeleton")
+		case "phone":
+			list = append(list, "phone_index")
+		}
+	}
+	return list
 }
 
 // Helper functions for legacy compatibility with main.go initialization
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"gorm.io/gorm"
+)
+
+// emailChangeRepository implements EmailChangeRepository interface
+type emailChangeRepository struct {
+	db *gorm.DB
+}
+
+// NewEmailChangeRepository creates a new email change repository
+func NewEmailChangeRepository(db *gorm.DB) EmailChangeRepository {
+	return &emailChangeRepository{db: db}
+}
+
+func (r *emailChangeRepository) Create(ctx context.Context, change *models.EmailChange) error {
+	return translateError(conn(ctx, r.db).Create(change).Error, nil)
+}
+
+func (r *emailChangeRepository) FindByConfirmTokenHash(ctx context.Context, hash string) (*models.EmailChange, error) {
+	return r.find(ctx, "confirm_token_hash = ?", hash)
+}
+
+func (r *emailChangeRepository) FindByCancelTokenHash(ctx context.Context, hash string) (*models.EmailChange, error) {
+	return r.find(ctx, "cancel_token_hash = ?", hash)
+}
+
+func (r *emailChangeRepository) Latest(ctx context.Context, userID uint) (*models.EmailChange, error) {
+	var change models.EmailChange
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("created_at DESC, id DESC").First(&change).Error
+	if err != nil {
+		return nil, translateError(err, ErrEmailChangeNotFound)
+	}
+	return &change, nil
+}
+
+func (r *emailChangeRepository) Update(ctx context.Context, change *models.EmailChange) error {
+	return translateError(conn(ctx, r.db).Save(change).Error, nil)
+}
+
+func (r *emailChangeRepository) CancelPending(ctx context.Context, userID uint, at time.Time) error {
+	err := conn(ctx, r.db).Model(&models.EmailChange{}).
+		Where("user_id = ? AND confirmed_at IS NULL AND cancelled_at IS NULL", userID).
+		Update("cancelled_at", at).Error
+	return translateError(err, nil)
+}
+
+func (r *emailChangeRepository) ListByUser(ctx context.Context, userID uint) ([]models.EmailChange, error) {
+	var changes []models.EmailChange
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("created_at, id").Find(&changes).Error
+	return changes, translateError(err, nil)
+}
+
+func (r *emailChangeRepository) find(ctx context.Context, query string, args ...interface{}) (*models.EmailChange, error) {
+	var change models.EmailChange
+	err := conn(ctx, r.db).Where(query, args...).First(&change).Error
+	if err != nil {
+		return nil, translateError(err, ErrEmailChangeNotFound)
+	}
+	return &change, nil
+}
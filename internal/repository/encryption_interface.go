@@ -0,0 +1,14 @@
+package repository
+
+import "context"
+
+// EncryptionRepository defines the interface for maintaining the encrypted columns after a key
+// rotation; EncryptedTables lists the tables that have them
+type EncryptionRepository interface {
+	// KeyUsage counts the stored encrypted values by the ID of the key that wraps them
+	KeyUsage(ctx context.Context) (map[string]int64, error)
+	// Reencrypt moves the values of up to limit rows of table with an ID above afterID from
+	// retired keys to the active key. It returns the last ID visited, 0 once no rows are left,
+	// and how many rows were rewritten.
+	Reencrypt(ctx context.Context, table string, afterID uint, limit int) (uint, int, error)
+}
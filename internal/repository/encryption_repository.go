@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/vayura/pkg/fieldcrypt"
+	"gorm.io/gorm"
+)
+
+// encryptedTable lists the columns of a table written by the encrypted serializer
+type encryptedTable struct {
+	Name    string
+	Columns []string
+}
+
+// encryptedTables are the tables with encrypted columns; rows are visited by their id column
+var encryptedTables = []encryptedTable{
+	{Name: "users", Columns: []string{"phone", "birthday"}},
+	{Name: "audit_entries", Columns: []string{"changes"}},
+	{Name: "profile_versions", Columns: []string{"phone", "birthday"}},
+	{Name: "phone_verifications", Columns: []string{"phone"}},
+}
+
+// EncryptedTables returns the names of the tables with encrypted columns
+func EncryptedTables() []string {
+	names := make([]string, len(encryptedTables))
+	for i, t := range encryptedTables {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// encryptionRepository implements EncryptionRepository interface
+type encryptionRepository struct {
+	db *gorm.DB
+}
+
+// NewEncryptionRepository creates a new encryption repository
+func NewEncryptionRepository(db *gorm.DB) EncryptionRepository {
+	return &encryptionRepository{db: db}
+}
+
+// encryptedRow holds the id and the raw ciphertexts of a row, in the order of the table's columns;
+// soft-deleted rows are included
+type encryptedRow struct {
+	ID     uint
+	Values []string
+}
+
+func (r *encryptionRepository) KeyUsage(ctx context.Context) (map[string]int64, error) {
+	usage := make(map[string]int64)
+	for _, table := range encryptedTables {
+		rows, err := r.rows(ctx, table, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			for _, value := range row.Values {
+				if value == "" {
+					continue
+				}
+				kid, err := fieldcrypt.KeyID(value)
+				if err != nil {
+					kid = "unencrypted"
+				}
+				usage[kid]++
+			}
+		}
+	}
+	return usage, nil
+}
+
+func (r *encryptionRepository) Reencrypt(ctx context.Context, tableName string, afterID uint, limit int) (uint, int, error) {
+	table, ok := findEncryptedTable(tableName)
+	if !ok {
+		return 0, 0, fmt.Errorf("table %s has no encrypted columns", tableName)
+	}
+	rows, err := r.rows(ctx, table, afterID, limit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rewritten := 0
+	for _, row := range rows {
+		updated := make([]interface{}, 0, 2*len(row.Values)+1)
+		changed := false
+		for i, value := range row.Values {
+			if fieldcrypt.NeedsReencryption(value) {
+				if value, err = fieldcrypt.Reencrypt(table.Name+"."+table.Columns[i], value); err != nil {
+					return 0, rewritten, err
+				}
+				changed = true
+			}
+			updated = append(updated, value)
+		}
+		if !changed {
+			continue
+		}
+
+		// Only replace the values read; a concurrent update already wrote them with the active key
+		set := make([]string, len(table.Columns))
+		where := make([]string, len(table.Columns))
+		for i, column := range table.Columns {
+			set[i] = column + " = ?"
+			where[i] = "COALESCE(" + column + ", '') = ?"
+		}
+		args := append(updated, row.ID)
+		for _, value := range row.Values {
+			args = append(args, value)
+		}
+		res := conn(ctx, r.db).Exec(
+			"UPDATE "+table.Name+" SET "+strings.Join(set, ", ")+" WHERE id = ? AND "+strings.Join(where, " AND "),
+			args...)
+		if res.Error != nil {
+			return 0, rewritten, translateError(res.Error, nil)
+		}
+		rewritten += int(res.RowsAffected)
+	}
+
+	if len(rows) < limit {
+		return 0, rewritten, nil
+	}
+	return rows[len(rows)-1].ID, rewritten, nil
+}
+
+// rows reads the encrypted columns of up to limit rows with an ID above afterID; 0 reads all of them
+func (r *encryptionRepository) rows(ctx context.Context, table encryptedTable, afterID uint, limit int) ([]encryptedRow, error) {
+	columns := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		columns[i] = "COALESCE(" + column + ", '')"
+	}
+	query := "SELECT id, " + strings.Join(columns, ", ") + " FROM " + table.Name + " WHERE id > ? ORDER BY id"
+	args := []interface{}{afterID}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	sqlRows, err := conn(ctx, r.db).Raw(query, args...).Rows()
+	if err != nil {
+		return nil, translateError(err, nil)
+	}
+	defer sqlRows.Close()
+
+	var rows []encryptedRow
+	for sqlRows.Next() {
+		row := encryptedRow{Values: make([]string, len(table.Columns))}
+		dest := []interface{}{&row.ID}
+		for i := range row.Values {
+			dest = append(dest, &row.Values[i])
+		}
+		if err := sqlRows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, translateError(sqlRows.Err(), nil)
+}
+
+func findEncryptedTable(name string) (encryptedTable, bool) {
+	for _, t := range encryptedTables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return encryptedTable{}, false
+}
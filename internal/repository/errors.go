@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/vayura/pkg"
+	"gorm.io/gorm"
+)
+
+// ErrUserNotFound is returned by every implementation when a lookup matches no active user
+var ErrUserNotFound = pkg.ErrUserNotFound
+
+// ErrSessionNotFound is returned when a session does not exist
+var ErrSessionNotFound = pkg.NewError(pkg.ErrNotFound, "SESSION_NOT_FOUND", "session not found")
+
+// ErrEmailChangeNotFound is returned when no email change request matches
+var ErrEmailChangeNotFound = pkg.ErrEmailChangeToken
+
+// ErrPhoneVerificationNotFound is returned when the user has no phone verification code
+var ErrPhoneVerificationNotFound = pkg.ErrOTPNotFound
+
+// ErrDataExportNotFound is returned when no data export matches
+var ErrDataExportNotFound = pkg.ErrExportNotFound
+
+// ErrProfileVersionNotFound is returned when the user has no profile version at the time asked for
+var ErrProfileVersionNotFound = pkg.ErrProfileVersionNotFound
+
+// ErrUsernameGrantNotFound is returned when a username has no grant
+var ErrUsernameGrantNotFound = pkg.ErrUsernameGrantNotFound
+
+// pgUniqueViolation is the SQLSTATE of a unique constraint violation
+const pgUniqueViolation = "23505"
+
+// translateError maps driver and GORM errors to typed application errors;
+// notFound replaces gorm.ErrRecordNotFound when it is not nil
+func translateError(err error, notFound error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound) && notFound != nil:
+		return notFound
+	case isUniqueViolation(err):
+		return uniqueViolationError(err)
+	case isUnavailable(err):
+		return pkg.WrapError(pkg.ErrUnavailable, "DATABASE_UNAVAILABLE", "database unavailable", err)
+	}
+	return err
+}
+
+// isUnavailable reports whether err means the database could not be reached in time
+func isUnavailable(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &netErr)
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation on Postgres or SQLite
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgUniqueViolation
+	}
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// uniqueViolationError maps a unique violation to the conflict of the violated column
+func uniqueViolationError(err error) error {
+	target := err.Error()
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		target = pgErr.ConstraintName
+	}
+	switch {
+	case strings.Contains(target, "email"):
+		return pkg.ErrEmailExists
+	case strings.Contains(target, "username"):
+		return pkg.ErrUsernameExists
+	}
+	return pkg.WrapError(pkg.ErrConflict, pkg.CodeConflict, "resource already exists", err)
+}
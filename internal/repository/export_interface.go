@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vayura/internal/models"
+)
+
+// DataExportRepository defines the interface for personal data export jobs
+type DataExportRepository interface {
+	Create(ctx context.Context, export *models.DataExport) error
+	FindByID(ctx context.Context, id uint) (*models.DataExport, error)
+	FindByTokenHash(ctx context.Context, hash string) (*models.DataExport, error)
+	// Latest returns the most recent export of the user, whatever its state
+	Latest(ctx context.Context, userID uint) (*models.DataExport, error)
+	// ListByStatus returns the exports in status, oldest first
+	ListByStatus(ctx context.Context, status string) ([]models.DataExport, error)
+	// ListExpired returns the ready exports whose link expired before now
+	ListExpired(ctx context.Context, now time.Time) ([]models.DataExport, error)
+	// Claim moves a pending export to running and reports false when another worker got it first
+	Claim(ctx context.Context, id uint) (bool, error)
+	Update(ctx context.Context, export *models.DataExport) error
+}
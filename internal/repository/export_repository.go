@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"gorm.io/gorm"
+)
+
+// dataExportRepository implements DataExportRepository interface
+type dataExportRepository struct {
+	db *gorm.DB
+}
+
+// NewDataExportRepository creates a new data export repository
+func NewDataExportRepository(db *gorm.DB) DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+func (r *dataExportRepository) Create(ctx context.Context, export *models.DataExport) error {
+	return translateError(conn(ctx, r.db).Create(export).Error, nil)
+}
+
+func (r *dataExportRepository) FindByID(ctx context.Context, id uint) (*models.DataExport, error) {
+	return r.find(ctx, "id = ?", id)
+}
+
+func (r *dataExportRepository) FindByTokenHash(ctx context.Context, hash string) (*models.DataExport, error) {
+	return r.find(ctx, "token_hash = ?", hash)
+}
+
+func (r *dataExportRepository) Latest(ctx context.Context, userID uint) (*models.DataExport, error) {
+	var export models.DataExport
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("created_at DESC, id DESC").First(&export).Error
+	if err != nil {
+		return nil, translateError(err, ErrDataExportNotFound)
+	}
+	return &export, nil
+}
+
+func (r *dataExportRepository) ListByStatus(ctx context.Context, status string) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := conn(ctx, r.db).Where("status = ?", status).Order("created_at, id").Find(&exports).Error
+	return exports, translateError(err, nil)
+}
+
+func (r *dataExportRepository) ListExpired(ctx context.Context, now time.Time) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := conn(ctx, r.db).Where("status = ? AND expires_at <= ?", models.ExportReady, now).Order("expires_at, id").Find(&exports).Error
+	return exports, translateError(err, nil)
+}
+
+func (r *dataExportRepository) Claim(ctx context.Context, id uint) (bool, error) {
+	res := conn(ctx, r.db).Model(&models.DataExport{}).
+		Where("id = ? AND status = ?", id, models.ExportPending).
+		Update("status", models.ExportRunning)
+	if res.Error != nil {
+		return false, translateError(res.Error, nil)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *dataExportRepository) Update(ctx context.Context, export *models.DataExport) error {
+	return translateError(conn(ctx, r.db).Save(export).Error, nil)
+}
+
+func (r *dataExportRepository) find(ctx context.Context, query string, args ...interface{}) (*models.DataExport, error) {
+	var export models.DataExport
+	err := conn(ctx, r.db).Where(query, args...).First(&export).Error
+	if err != nil {
+		return nil, translateError(err, ErrDataExportNotFound)
+	}
+	return &export, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"sync"
+)
+
+// memoryTxKey marks a ctx that runs inside a memory transaction
+type memoryTxKey struct{}
+
+// snapshotter is implemented by in-memory repositories; restore puts back the state at snapshot time
+type snapshotter interface {
+	snapshot() (restore func())
+}
+
+// memoryTxManager implements TxManager for in-memory repositories by restoring snapshots on failure.
+// Transactions are serialized against each other but not isolated from calls made outside them.
+type memoryTxManager struct {
+	mu    sync.Mutex
+	repos []snapshotter
+}
+
+// NewMemoryTxManager creates a transaction manager covering the given in-memory repositories;
+// repositories that are not in-memory are ignored
+func NewMemoryTxManager(repos ...interface{}) TxManager {
+	m := &memoryTxManager{}
+	for _, r := range repos {
+		if s, ok := r.(snapshotter); ok {
+			m.repos = append(m.repos, s)
+		}
+	}
+	return m
+}
+
+func (m *memoryTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if ctx.Value(memoryTxKey{}) == nil {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		ctx = context.WithValue(ctx, memoryTxKey{}, true)
+	}
+
+	restores := make([]func(), 0, len(m.repos))
+	for _, r := range m.repos {
+		restores = append(restores, r.snapshot())
+	}
+	rollback := func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+	if err = fn(ctx); err != nil {
+		rollback()
+	}
+	return err
+}
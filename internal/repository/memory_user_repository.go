@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/identity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// memoryUserRepository is a thread-safe in-memory UserRepository with the same
+// semantics as the GORM implementation: normalized email and username, unique email and
+// username skeleton (also across soft-deleted rows, like the database indexes), soft delete
+// and ErrUserNotFound.
+type memoryUserRepository struct {
+	mu     sync.RWMutex
+	users  map[uint]models.User
+	nextID uint
+}
+
+// userSchema maps users column names to model fields for column-scoped updates
+var userSchema = func() *schema.Schema {
+	s, err := schema.Parse(&models.User{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		panic(err)
+	}
+	return s
+}()
+
+// NewMemoryUserRepository creates an empty in-memory user repository
+func NewMemoryUserRepository() UserRepository {
+	return &memoryUserRepository{users: make(map[uint]models.User), nextID: 1}
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	if err := user.NormalizeIdentity(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.taken(user, 0); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if user.ID == 0 {
+		user.ID = r.nextID
+	} else if _, exists := r.users[user.ID]; exists {
+		return pkg.NewError(pkg.ErrConflict, pkg.CodeConflict, "resource already exists")
+	}
+	if user.ID >= r.nextID {
+		r.nextID = user.ID + 1
+	}
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	if user.Version == 0 {
+		user.Version = 1
+	}
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = now
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	email, err := identity.NormalizeEmail(email)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return r.findActive(func(u models.User) bool { return u.Email == email })
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	return r.findActive(func(u models.User) bool { return u.ID == id })
+}
+
+func (r *memoryUserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	username = identity.NormalizeUsername(username)
+	return r.findActive(func(u models.User) bool { return strings.EqualFold(u.Username, username) })
+}
+
+func (r *memoryUserRepository) FindByPhone(ctx context.Context, phone string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *models.User
+	for _, u := range r.users {
+		if phone != "" && !u.DeletedAt.Valid && u.Phone == phone && (found == nil || u.ID < found.ID) {
+			u := u
+			found = &u
+		}
+	}
+	if found == nil {
+		return nil, ErrUserNotFound
+	}
+	return found, nil
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, user *models.User, columns ...string) error {
+	if err := user.NormalizeIdentity(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return ErrUserNotFound
+	}
+	if stored.Version != user.Version {
+		return pkg.ErrVersionMismatch
+	}
+
+	updated := *user
+	if len(columns) > 0 {
+		updated = stored
+		for _, column := range updatedColumns(columns) {
+			field := userSchema.LookUpField(column)
+			if field == nil {
+				return fmt.Errorf("unknown users column %q", column)
+			}
+			field.ReflectValueOf(ctx, reflect.ValueOf(&updated).Elem()).Set(field.ReflectValueOf(ctx, reflect.ValueOf(user).Elem()))
+		}
+	}
+	updated.ID, updated.CreatedAt, updated.DeletedAt = stored.ID, stored.CreatedAt, stored.DeletedAt
+	if err := r.taken(&updated, user.ID); err != nil {
+		return err
+	}
+
+	updated.Version = stored.Version + 1
+	updated.UpdatedAt = time.Now()
+	r.users[user.ID] = updated
+	user.Version, user.UpdatedAt = updated.Version, updated.UpdatedAt
+	return nil
+}
+
+func (r *memoryUserRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok || u.DeletedAt.Valid {
+		return nil
+	}
+	u.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.users[id] = u
+	return nil
+}
+
+func (r *memoryUserRepository) FindDeletedByEmail(ctx context.Context, email string) (*models.User, error) {
+	email, err := identity.NormalizeEmail(email)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.DeletedAt.Valid && u.Email == email {
+			found := u
+			return &found, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (r *memoryUserRepository) Restore(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok || !stored.DeletedAt.Valid {
+		return ErrUserNotFound
+	}
+	stored.DeletedAt = gorm.DeletedAt{}
+	stored.Version++
+	stored.UpdatedAt = time.Now()
+	r.users[user.ID] = stored
+	user.DeletedAt, user.Version, user.UpdatedAt = stored.DeletedAt, stored.Version, stored.UpdatedAt
+	return nil
+}
+
+func (r *memoryUserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	_, err := r.FindByEmail(ctx, email)
+	return err == nil, nil
+}
+
+func (r *memoryUserRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
+	skeleton := identity.Skeleton(username)
+	_, err := r.findActive(func(u models.User) bool { return u.UsernameSkeleton == skeleton })
+	return err == nil, nil
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, opts ListOptions) ([]models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	search := strings.ToLower(opts.Search)
+	users := make([]models.User, 0, len(r.users))
+	for _, u := range r.users {
+		switch {
+		case opts.OnlyDeleted:
+			if !u.DeletedAt.Valid || (!opts.DeletedBefore.IsZero() && !u.DeletedAt.Time.Before(opts.DeletedBefore)) {
+				continue
+			}
+		case !opts.IncludeDeleted:
+			if u.DeletedAt.Valid {
+				continue
+			}
+		}
+		if opts.Role != "" && u.Role != opts.Role {
+			continue
+		}
+		if search != "" &&
+			!strings.Contains(strings.ToLower(u.Username), search) &&
+			!strings.Contains(strings.ToLower(u.Email), search) &&
+			!strings.Contains(strings.ToLower(u.FullName), search) {
+			continue
+		}
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	if opts.Offset > 0 {
+		if opts.Offset >= len(users) {
+			return []models.User{}, nil
+		}
+		users = users[opts.Offset:]
+	}
+	if opts.Limit > 0 && opts.Limit < len(users) {
+		users = users[:opts.Limit]
+	}
+	return users, nil
+}
+
+func (r *memoryUserRepository) HardDelete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, id)
+	return nil
+}
+
+// snapshot copies the stored users so a memory transaction can roll back
+func (r *memoryUserRepository) snapshot() func() {
+	r.mu.RLock()
+	users := make(map[uint]models.User, len(r.users))
+	for id, u := range r.users {
+		users[id] = u
+	}
+	nextID := r.nextID
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.users, r.nextID = users, nextID
+		r.mu.Unlock()
+	}
+}
+
+// findActive returns a copy of the first non-deleted user matching fn
+func (r *memoryUserRepository) findActive(fn func(u models.User) bool) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if !u.DeletedAt.Valid && fn(u) {
+			found := u
+			return &found, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// taken returns the conflict when the email or username skeleton of user belongs to another row,
+// deleted or not; callers hold the lock
+func (r *memoryUserRepository) taken(user *models.User, exceptID uint) error {
+	for id, u := range r.users {
+		if id == exceptID {
+			continue
+		}
+		if u.Email == user.Email {
+			return pkg.ErrEmailExists
+		}
+		if u.UsernameSkeleton == user.UsernameSkeleton {
+			return pkg.ErrUsernameExists
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vayura/internal/models"
+	"gorm.io/gorm"
+)
+
+// phoneVerificationRepository implements PhoneVerificationRepository interface
+type phoneVerificationRepository struct {
+	db *gorm.DB
+}
+
+// NewPhoneVerificationRepository creates a new phone verification repository
+func NewPhoneVerificationRepository(db *gorm.DB) PhoneVerificationRepository {
+	return &phoneVerificationRepository{db: db}
+}
+
+func (r *phoneVerificationRepository) Create(ctx context.Context, verification *models.PhoneVerification) error {
+	return translateError(conn(ctx, r.db).Create(verification).Error, nil)
+}
+
+func (r *phoneVerificationRepository) Latest(ctx context.Context, userID uint) (*models.PhoneVerification, error) {
+	var verification models.PhoneVerification
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("created_at DESC, id DESC").First(&verification).Error
+	if err != nil {
+		return nil, translateError(err, ErrPhoneVerificationNotFound)
+	}
+	return &verification, nil
+}
+
+func (r *phoneVerificationRepository) UseAttempt(ctx context.Context, id uint, max int) (bool, error) {
+	// A single conditional update, so concurrent guesses cannot exceed max
+	res := conn(ctx, r.db).Model(&models.PhoneVerification{}).
+		Where("id = ? AND attempts < ?", id, max).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if res.Error != nil {
+		return false, translateError(res.Error, nil)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *phoneVerificationRepository) Update(ctx context.Context, verification *models.PhoneVerification) error {
+	return translateError(conn(ctx, r.db).Save(verification).Error, nil)
+}
+
+func (r *phoneVerificationRepository) ListByUser(ctx context.Context, userID uint) ([]models.PhoneVerification, error) {
+	var verifications []models.PhoneVerification
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("created_at, id").Find(&verifications).Error
+	return verifications, translateError(err, nil)
+}
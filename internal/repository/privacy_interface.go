@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vayura/internal/models"
+)
+
+// PrivacyRepository defines the interface for profile visibility settings and user relations
+type PrivacyRepository interface {
+	// Visibility returns the visibility the user chose per field; fields never changed are absent
+	Visibility(ctx context.Context, userID uint) (map[string]string, error)
+	SetVisibility(ctx context.Context, userID uint, visibility map[string]string) error
+
+	// Relation returns the kind of relation from userID to otherID, or "" when there is none
+	Relation(ctx context.Context, userID, otherID uint) (string, error)
+	// SetRelation creates or replaces the relation from userID to otherID
+	SetRelation(ctx context.Context, relation *models.Relation) error
+	// DeleteRelation removes the relation of kind from userID to otherID, if any
+	DeleteRelation(ctx context.Context, userID, otherID uint, kind string) error
+	// ListRelated returns the users userID has a relation of kind with, oldest first
+	ListRelated(ctx context.Context, userID uint, kind string) ([]models.User, error)
+}
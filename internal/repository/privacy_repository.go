@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vayura/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// privacyRepository implements PrivacyRepository interface
+type privacyRepository struct {
+	db *gorm.DB
+}
+
+// NewPrivacyRepository creates a new privacy repository
+func NewPrivacyRepository(db *gorm.DB) PrivacyRepository {
+	return &privacyRepository{db: db}
+}
+
+func (r *privacyRepository) Visibility(ctx context.Context, userID uint) (map[string]string, error) {
+	var rows []models.FieldVisibility
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return nil, translateError(err, nil)
+	}
+	visibility := make(map[string]string, len(rows))
+	for _, row := range rows {
+		visibility[row.Field] = row.Visibility
+	}
+	return visibility, nil
+}
+
+func (r *privacyRepository) SetVisibility(ctx context.Context, userID uint, visibility map[string]string) error {
+	if len(visibility) == 0 {
+		return nil
+	}
+	rows := make([]models.FieldVisibility, 0, len(visibility))
+	for field, v := range visibility {
+		rows = append(rows, models.FieldVisibility{UserID: userID, Field: field, Visibility: v})
+	}
+	err := conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "field"}},
+		DoUpdates: clause.AssignmentColumns([]string{"visibility"}),
+	}).Create(&rows).Error
+	return translateError(err, nil)
+}
+
+func (r *privacyRepository) Relation(ctx context.Context, userID, otherID uint) (string, error) {
+	var relation models.Relation
+	err := conn(ctx, r.db).Where("user_id = ? AND other_id = ?", userID, otherID).First(&relation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", translateError(err, nil)
+	}
+	return relation.Kind, nil
+}
+
+func (r *privacyRepository) SetRelation(ctx context.Context, relation *models.Relation) error {
+	err := conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "other_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"kind", "created_at"}),
+	}).Create(relation).Error
+	return translateError(err, nil)
+}
+
+func (r *privacyRepository) DeleteRelation(ctx context.Context, userID, otherID uint, kind string) error {
+	err := conn(ctx, r.db).Where("user_id = ? AND other_id = ? AND kind = ?", userID, otherID, kind).Delete(&models.Relation{}).Error
+	return translateError(err, nil)
+}
+
+func (r *privacyRepository) ListRelated(ctx context.Context, userID uint, kind string) ([]models.User, error) {
+	var users []models.User
+	err := conn(ctx, r.db).
+		Joins("JOIN user_relations ON user_relations.other_id = users.id").
+		Where("user_relations.user_id = ? AND user_relations.kind = ?", userID, kind).
+		Order("user_relations.created_at, users.id").
+		Find(&users).Error
+	if err != nil {
+		return nil, translateError(err, nil)
+	}
+	return users, nil
+}
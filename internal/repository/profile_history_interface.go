@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vayura/internal/models"
+)
+
+// ProfileHistoryRepository defines the interface for the versions of user profiles
+type ProfileHistoryRepository interface {
+	UserAnonymizer
+	// Current returns the current version of the user's profile, ErrProfileVersionNotFound if there is none
+	Current(ctx context.Context, userID uint) (*models.ProfileVersion, error)
+	// Append closes the current version of the user at version.CreatedAt and adds version
+	Append(ctx context.Context, version *models.ProfileVersion) error
+	// List returns up to limit versions of the user older than beforeID (0 for the newest), newest first
+	List(ctx context.Context, userID, beforeID uint, limit int) ([]models.ProfileVersion, error)
+	// AsOf returns the version that was current at t, ErrProfileVersionNotFound if the user had no profile yet
+	AsOf(ctx context.Context, userID uint, t time.Time) (*models.ProfileVersion, error)
+	// LastReleased returns when another user than userID last stopped using a username with the
+	// given skeleton, the zero time if no one did
+	LastReleased(ctx context.Context, skeleton string, userID uint) (time.Time, error)
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"gorm.io/gorm"
+)
+
+// profileHistoryRepository implements ProfileHistoryRepository interface
+type profileHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewProfileHistoryRepository creates a new profile history repository
+func NewProfileHistoryRepository(db *gorm.DB) ProfileHistoryRepository {
+	return &profileHistoryRepository{db: db}
+}
+
+func (r *profileHistoryRepository) Current(ctx context.Context, userID uint) (*models.ProfileVersion, error) {
+	var version models.ProfileVersion
+	err := conn(ctx, r.db).Where("user_id = ? AND valid_to IS NULL", userID).Order("id DESC").First(&version).Error
+	if err != nil {
+		return nil, translateError(err, ErrProfileVersionNotFound)
+	}
+	return &version, nil
+}
+
+func (r *profileHistoryRepository) Append(ctx context.Context, version *models.ProfileVersion) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.ProfileVersion{}).Where("user_id = ? AND valid_to IS NULL", version.UserID).
+			Update("valid_to", version.CreatedAt).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(version).Error
+	})
+	return translateError(err, nil)
+}
+
+func (r *profileHistoryRepository) List(ctx context.Context, userID, beforeID uint, limit int) ([]models.ProfileVersion, error) {
+	query := conn(ctx, r.db).Where("user_id = ?", userID)
+	if beforeID != 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var versions []models.ProfileVersion
+	err := query.Order("id DESC").Find(&versions).Error
+	return versions, translateError(err, nil)
+}
+
+func (r *profileHistoryRepository) AsOf(ctx context.Context, userID uint, t time.Time) (*models.ProfileVersion, error) {
+	var version models.ProfileVersion
+	err := conn(ctx, r.db).Where("user_id = ? AND created_at <= ?", userID, t).
+		Order("created_at DESC, id DESC").First(&version).Error
+	if err != nil {
+		return nil, translateError(err, ErrProfileVersionNotFound)
+	}
+	return &version, nil
+}
+
+func (r *profileHistoryRepository) LastReleased(ctx context.Context, skeleton string, userID uint) (time.Time, error) {
+	var versions []models.ProfileVersion
+	err := conn(ctx, r.db).Select("valid_to").
+		Where("username_skeleton = ? AND user_id <> ? AND valid_to IS NOT NULL", skeleton, userID).
+		Order("valid_to DESC").Limit(1).Find(&versions).Error
+	if err != nil || len(versions) == 0 {
+		return time.Time{}, translateError(err, nil)
+	}
+	return *versions[0].ValidTo, nil
+}
+
+// AnonymizeUser closes the current version, which releases the username, and clears the profile
+// fields of every version; the username skeleton and the times stay for the reuse cooldown
+func (r *profileHistoryRepository) AnonymizeUser(ctx context.Context, userID uint) error {
+	db := conn(ctx, r.db)
+	err := db.Model(&models.ProfileVersion{}).Where("user_id = ? AND valid_to IS NULL", userID).
+		Update("valid_to", time.Now()).Error
+	if err != nil {
+		return translateError(err, nil)
+	}
+	err = db.Model(&models.ProfileVersion{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+		"full_name": "", "username": "", "email": "", "phone": "", "avatar": "", "gender": "", "birthday": "",
+	}).Error
+	return translateError(err, nil)
+}
@@ -0,0 +1,92 @@
+// Package repotest provides database backends and contract suites shared by repository tests.
+package repotest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vayura/config"
+	"github.com/vayura/internal/migrate"
+	"github.com/vayura/pkg/fieldcrypt"
+	"gorm.io/gorm"
+)
+
+// Backend opens a fresh, fully migrated database for a single test
+type Backend struct {
+	Name string
+	Open func(t testing.TB) *gorm.DB
+}
+
+// Backends returns the database backends of the test run. SQLite always runs, in memory and
+// from a file; Postgres runs when VAYURA_TEST_POSTGRES_DB is set (connection settings come from
+// the usual DB_* variables) and its tests are skipped otherwise.
+func Backends() []Backend {
+	return []Backend{
+		{Name: config.DriverSQLite, Open: OpenSQLite},
+		{Name: config.DriverSQLite + "-file", Open: OpenSQLiteFile},
+		{Name: config.DriverPostgres, Open: OpenPostgres},
+	}
+}
+
+// OpenSQLite opens a migrated in-memory SQLite database. Its pool holds a single connection, so
+// statements never run concurrently; use OpenSQLiteFile for races.
+func OpenSQLite(t testing.TB) *gorm.DB {
+	t.Helper()
+	return open(t, config.DatabaseConfig{Driver: config.DriverSQLite, Path: ":memory:"})
+}
+
+// OpenSQLiteFile opens a migrated SQLite database in a temporary file, in WAL mode with a pool
+// of several connections like a server uses
+func OpenSQLiteFile(t testing.TB) *gorm.DB {
+	t.Helper()
+	return open(t, config.DatabaseConfig{Driver: config.DriverSQLite, Path: filepath.Join(t.TempDir(), "vayura.db")})
+}
+
+// OpenPostgres opens the Postgres test database, migrated up and reverted when the test ends
+func OpenPostgres(t testing.TB) *gorm.DB {
+	t.Helper()
+	dbName := os.Getenv("VAYURA_TEST_POSTGRES_DB")
+	if dbName == "" {
+		t.Skip("VAYURA_TEST_POSTGRES_DB not set")
+	}
+	cfg := config.Load().Database
+	cfg.Driver = config.DriverPostgres
+	cfg.DBName = dbName
+	return open(t, cfg)
+}
+
+func open(t testing.TB, cfg config.DatabaseConfig) *gorm.DB {
+	t.Helper()
+	if !fieldcrypt.Configured() {
+		kr, err := fieldcrypt.NewKeyring()
+		if err != nil {
+			t.Fatalf("generate PII keyring: %v", err)
+		}
+		fieldcrypt.SetKeyring(kr)
+	}
+	db, err := config.InitDatabase(cfg)
+	if err != nil {
+		t.Fatalf("open %s database: %v", cfg.Driver, err)
+	}
+
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("migrate %s database: %v", cfg.Driver, err)
+	}
+
+	t.Cleanup(func() {
+		if _, err := m.Down(ctx, len(m.Migrations())); err != nil {
+			t.Errorf("revert %s database: %v", cfg.Driver, err)
+		}
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
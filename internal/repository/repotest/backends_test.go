@@ -0,0 +1,26 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vayura/internal/migrate"
+)
+
+// TestBackends checks that every backend opens fully migrated; Postgres is skipped unless
+// VAYURA_TEST_POSTGRES_DB is set
+func TestBackends(t *testing.T) {
+	for _, b := range Backends() {
+		b := b
+		t.Run(b.Name, func(t *testing.T) {
+			db := b.Open(t)
+			m, err := migrate.New(db)
+			if err != nil {
+				t.Fatalf("load migrations: %v", err)
+			}
+			if err := m.Verify(context.Background()); err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vayura/internal/repository"
+)
+
+// NewTxFunc returns an empty user repository and the transaction manager covering it
+type NewTxFunc func(t *testing.T) (repository.TxManager, repository.UserRepository)
+
+// RunTxManagerBackends runs the TxManager suite against the GORM implementation on every backend
+func RunTxManagerBackends(t *testing.T) {
+	for _, b := range Backends() {
+		b := b
+		t.Run(b.Name, func(t *testing.T) {
+			RunTxManagerSuite(t, func(t *testing.T) (repository.TxManager, repository.UserRepository) {
+				db := b.Open(t)
+				return repository.NewTxManager(db), repository.NewUserRepository(db)
+			})
+		})
+	}
+}
+
+// RunTxManagerSuite checks the commit, rollback and savepoint semantics every TxManager must have
+func RunTxManagerSuite(t *testing.T, newTx NewTxFunc) {
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	t.Run("Commit", func(t *testing.T) {
+		tm, repo := newTx(t)
+		err := tm.WithinTx(ctx, func(ctx context.Context) error {
+			return repo.Create(ctx, fixture("ann"))
+		})
+		if err != nil {
+			t.Fatalf("WithinTx: %v", err)
+		}
+		if _, err := repo.FindByUsername(ctx, "ann"); err != nil {
+			t.Fatalf("committed user not found: %v", err)
+		}
+	})
+
+	t.Run("RollbackOnError", func(t *testing.T) {
+		tm, repo := newTx(t)
+		err := tm.WithinTx(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, fixture("ben")); err != nil {
+				return err
+			}
+			if _, err := repo.FindByUsername(ctx, "ben"); err != nil {
+				t.Errorf("user not visible inside its transaction: %v", err)
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("WithinTx = %v, want the error returned by fn", err)
+		}
+		if _, err := repo.FindByUsername(ctx, "ben"); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("rolled back user: FindByUsername = %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("RollbackOnPanic", func(t *testing.T) {
+		tm, repo := newTx(t)
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("WithinTx swallowed the panic")
+				}
+			}()
+			tm.WithinTx(ctx, func(ctx context.Context) error {
+				if err := repo.Create(ctx, fixture("cal")); err != nil {
+					return err
+				}
+				panic("boom")
+			})
+		}()
+		if _, err := repo.FindByUsername(ctx, "cal"); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("user created before the panic: FindByUsername = %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("NestedSavepoint", func(t *testing.T) {
+		tm, repo := newTx(t)
+		err := tm.WithinTx(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, fixture("dee")); err != nil {
+				return err
+			}
+			inner := tm.WithinTx(ctx, func(ctx context.Context) error {
+				if err := repo.Create(ctx, fixture("eve")); err != nil {
+					return err
+				}
+				return errAbort
+			})
+			if !errors.Is(inner, errAbort) {
+				t.Errorf("inner WithinTx = %v, want the error returned by fn", inner)
+			}
+			return repo.Create(ctx, fixture("fay"))
+		})
+		if err != nil {
+			t.Fatalf("outer WithinTx: %v", err)
+		}
+		for name, want := range map[string]bool{"dee": true, "eve": false, "fay": true} {
+			_, err := repo.FindByUsername(ctx, name)
+			if got := err == nil; got != want {
+				t.Errorf("%s exists = %v, want %v (err %v)", name, got, want, err)
+			}
+		}
+	})
+
+	t.Run("OuterRollbackUndoesInner", func(t *testing.T) {
+		tm, repo := newTx(t)
+		err := tm.WithinTx(ctx, func(ctx context.Context) error {
+			if err := tm.WithinTx(ctx, func(ctx context.Context) error {
+				return repo.Create(ctx, fixture("gus"))
+			}); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("WithinTx = %v, want the error returned by fn", err)
+		}
+		if _, err := repo.FindByUsername(ctx, "gus"); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("inner commit survived the outer rollback: FindByUsername = %v", err)
+		}
+	})
+}
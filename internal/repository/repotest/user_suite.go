@@ -0,0 +1,458 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/pkg"
+)
+
+// NewUserRepositoryFunc returns an empty repository for a single subtest
+type NewUserRepositoryFunc func(t *testing.T) repository.UserRepository
+
+// RunUserRepositoryBackends runs the UserRepository suite against the GORM repository on every backend
+func RunUserRepositoryBackends(t *testing.T) {
+	for _, b := range Backends() {
+		b := b
+		t.Run(b.Name, func(t *testing.T) {
+			RunUserRepositorySuite(t, func(t *testing.T) repository.UserRepository {
+				return repository.NewUserRepository(b.Open(t))
+			})
+		})
+	}
+}
+
+// RunUserRepositorySuite is the UserRepository contract: every implementation,
+// including custom backends, must pass it to stay interchangeable
+func RunUserRepositorySuite(t *testing.T, newRepo NewUserRepositoryFunc) {
+	ctx := context.Background()
+
+	t.Run("CreateAndFind", func(t *testing.T) {
+		repo := newRepo(t)
+		user := fixture("alice")
+		mustCreate(t, repo, user)
+		if user.ID == 0 {
+			t.Fatal("Create did not assign an ID")
+		}
+
+		byID, err := repo.FindByID(ctx, user.ID)
+		if err != nil || byID.Username != "alice" {
+			t.Fatalf("FindByID = %v, %v", byID, err)
+		}
+		byEmail, err := repo.FindByEmail(ctx, "alice@example.com")
+		if err != nil || byEmail.ID != user.ID {
+			t.Fatalf("FindByEmail = %v, %v", byEmail, err)
+		}
+		byUsername, err := repo.FindByUsername(ctx, "alice")
+		if err != nil || byUsername.ID != user.ID {
+			t.Fatalf("FindByUsername = %v, %v", byUsername, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		if u, err := repo.FindByID(ctx, 4242); !errors.Is(err, repository.ErrUserNotFound) || u != nil {
+			t.Fatalf("FindByID of missing user = %v, %v; want ErrUserNotFound", u, err)
+		}
+		if u, err := repo.FindByEmail(ctx, "nobody@example.com"); !errors.Is(err, repository.ErrUserNotFound) || u != nil {
+			t.Fatalf("FindByEmail of missing user = %v, %v; want ErrUserNotFound", u, err)
+		}
+		if u, err := repo.FindByUsername(ctx, "nobody"); !errors.Is(err, repository.ErrUserNotFound) || u != nil {
+			t.Fatalf("FindByUsername of missing user = %v, %v; want ErrUserNotFound", u, err)
+		}
+	})
+
+	t.Run("Unique", func(t *testing.T) {
+		repo := newRepo(t)
+		mustCreate(t, repo, fixture("kim"))
+
+		sameEmail := fixture("kim2")
+		sameEmail.Email = "kim@example.com"
+		if err := repo.Create(ctx, sameEmail); !errors.Is(err, pkg.ErrEmailExists) {
+			t.Fatalf("Create with a duplicate email = %v, want ErrEmailExists", err)
+		}
+		sameUsername := fixture("kim")
+		sameUsername.Email = "kim2@example.com"
+		if err := repo.Create(ctx, sameUsername); !errors.Is(err, pkg.ErrUsernameExists) {
+			t.Fatalf("Create with a duplicate username = %v, want ErrUsernameExists", err)
+		}
+
+		caseEmail := fixture("kim3")
+		caseEmail.Email = "KIM@Example.com"
+		if err := repo.Create(ctx, caseEmail); !errors.Is(err, pkg.ErrEmailExists) {
+			t.Fatalf("Create with a case-variant email = %v, want ErrEmailExists", err)
+		}
+		caseUsername := fixture("KIM")
+		caseUsername.Email = "kim4@example.com"
+		if err := repo.Create(ctx, caseUsername); !errors.Is(err, pkg.ErrUsernameExists) {
+			t.Fatalf("Create with a case-variant username = %v, want ErrUsernameExists", err)
+		}
+
+		other := fixture("lee")
+		mustCreate(t, repo, other)
+		other.Username = "Kim"
+		if err := repo.Update(ctx, other); !errors.Is(err, pkg.ErrUsernameExists) {
+			t.Fatalf("Update to a taken username = %v, want ErrUsernameExists", err)
+		}
+		if ok, err := repo.EmailExists(ctx, "Kim@EXAMPLE.com"); err != nil || !ok {
+			t.Fatalf("EmailExists(case variant) = %v, %v; want true", ok, err)
+		}
+		if ok, err := repo.UsernameExists(ctx, "KIM"); err != nil || !ok {
+			t.Fatalf("UsernameExists(case variant) = %v, %v; want true", ok, err)
+		}
+	})
+
+	t.Run("Normalization", func(t *testing.T) {
+		repo := newRepo(t)
+		user := fixture("John")
+		user.Email = "  John.Doe@Example.COM "
+		mustCreate(t, repo, user)
+		if user.Email != "john.doe@example.com" {
+			t.Fatalf("stored Email = %q, want it trimmed and lowercased", user.Email)
+		}
+
+		if got, err := repo.FindByEmail(ctx, "JOHN.DOE@example.com"); err != nil || got.ID != user.ID {
+			t.Fatalf("FindByEmail(case variant) = %v, %v", got, err)
+		}
+		if got, err := repo.FindByUsername(ctx, "ｊｏｈｎ"); err != nil || got.ID != user.ID {
+			t.Fatalf("FindByUsername(fullwidth, lowercase) = %v, %v", got, err)
+		}
+		if _, err := repo.FindByUsername(ctx, "j0hn"); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("FindByUsername(look-alike) = %v, want ErrUserNotFound", err)
+		}
+
+		idn := fixture("reader")
+		idn.Email = "reader@Bücher.example"
+		mustCreate(t, repo, idn)
+		if got, err := repo.FindByEmail(ctx, "reader@xn--bcher-kva.example"); err != nil || got.ID != idn.ID {
+			t.Fatalf("FindByEmail(punycode) = %v, %v", got, err)
+		}
+
+		for _, name := range []string{"jоhn", "j0hn", "JÖHN"} {
+			if ok, err := repo.UsernameExists(ctx, name); err != nil || !ok {
+				t.Fatalf("UsernameExists(%q) = %v, %v; want true", name, ok, err)
+			}
+			lookalike := fixture(name)
+			lookalike.Email = "lookalike@example.com"
+			if err := repo.Create(ctx, lookalike); !errors.Is(err, pkg.ErrUsernameExists) {
+				t.Fatalf("Create(%q) = %v, want ErrUsernameExists", name, err)
+			}
+		}
+	})
+
+	t.Run("ConcurrentDuplicate", func(t *testing.T) {
+		repo := newRepo(t)
+		const workers = 10
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				user := fixture(fmt.Sprintf("racer%d", i))
+				user.Email = "racer@example.com"
+				if i%2 == 1 {
+					user.Email = "Racer@Example.com"
+				}
+				errs <- repo.Create(ctx, user)
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+
+		created := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				created++
+			case !errors.Is(err, pkg.ErrEmailExists):
+				t.Fatalf("losing Create = %v, want ErrEmailExists", err)
+			}
+		}
+		if created != 1 {
+			t.Fatalf("%d concurrent registrations with the same email succeeded, want 1", created)
+		}
+	})
+
+	t.Run("DefaultsAndTimestamps", func(t *testing.T) {
+		repo := newRepo(t)
+		user := fixture("lena")
+		user.Role = ""
+		mustCreate(t, repo, user)
+
+		got, err := repo.FindByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if got.Role != models.RoleUser {
+			t.Fatalf("Role = %q, want default %q", got.Role, models.RoleUser)
+		}
+		if got.CreatedAt.IsZero() || got.UpdatedAt.IsZero() {
+			t.Fatal("timestamps were not set on create")
+		}
+	})
+
+	t.Run("ReturnsCopies", func(t *testing.T) {
+		repo := newRepo(t)
+		user := fixture("mona")
+		mustCreate(t, repo, user)
+
+		got, _ := repo.FindByID(ctx, user.ID)
+		got.FullName = "changed without Update"
+		again, _ := repo.FindByID(ctx, user.ID)
+		if again.FullName != "User mona" {
+			t.Fatal("mutating a returned user changed the stored user")
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		repo := newRepo(t)
+		var wg sync.WaitGroup
+		errs := make(chan error, 20)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs <- repo.Create(ctx, fixture(fmt.Sprintf("worker%d", i)))
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("concurrent Create: %v", err)
+			}
+		}
+		all, err := repo.List(ctx, repository.ListOptions{})
+		if err != nil || len(all) != 20 {
+			t.Fatalf("List = %d users, %v; want 20", len(all), err)
+		}
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		repo := newRepo(t)
+		mustCreate(t, repo, fixture("bob"))
+
+		if ok, err := repo.EmailExists(ctx, "bob@example.com"); err != nil || !ok {
+			t.Fatalf("EmailExists = %v, %v; want true", ok, err)
+		}
+		if ok, err := repo.EmailExists(ctx, "carol@example.com"); err != nil || ok {
+			t.Fatalf("EmailExists = %v, %v; want false", ok, err)
+		}
+		if ok, err := repo.UsernameExists(ctx, "bob"); err != nil || !ok {
+			t.Fatalf("UsernameExists = %v, %v; want true", ok, err)
+		}
+		if ok, err := repo.UsernameExists(ctx, "carol"); err != nil || ok {
+			t.Fatalf("UsernameExists = %v, %v; want false", ok, err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		user := fixture("dave")
+		mustCreate(t, repo, user)
+
+		user.FullName = "Dave Updated"
+		user.Phone = "+628123456789"
+		if err := repo.Update(ctx, user); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		got, err := repo.FindByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if got.FullName != "Dave Updated" || got.Phone != "+628123456789" {
+			t.Fatalf("Update not persisted: %+v", got)
+		}
+	})
+
+	t.Run("VersionedUpdate", func(t *testing.T) {
+		repo := newRepo(t)
+		user := fixture("vera")
+		mustCreate(t, repo, user)
+		if user.Version != 1 {
+			t.Fatalf("Version after Create = %d, want 1", user.Version)
+		}
+
+		stale, err := repo.FindByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		user.FullName = "Vera First"
+		if err := repo.Update(ctx, user); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if user.Version != 2 {
+			t.Fatalf("Version after Update = %d, want 2", user.Version)
+		}
+
+		stale.FullName = "Vera Second"
+		if err := repo.Update(ctx, stale, "full_name"); !errors.Is(err, pkg.ErrVersionMismatch) {
+			t.Fatalf("Update of a stale copy = %v, want ErrVersionMismatch", err)
+		}
+		if stale.Version != 1 {
+			t.Fatalf("failed Update changed Version to %d", stale.Version)
+		}
+
+		user.FullName = "Vera Scoped"
+		user.Phone = "+628111111111"
+		if err := repo.Update(ctx, user, "full_name"); err != nil {
+			t.Fatalf("column-scoped Update: %v", err)
+		}
+		got, err := repo.FindByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if got.FullName != "Vera Scoped" || got.Phone != "" || got.Version != 3 {
+			t.Fatalf("column-scoped Update stored %q, phone %q, version %d", got.FullName, got.Phone, got.Version)
+		}
+
+		if err := repo.Delete(ctx, user.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if err := repo.Update(ctx, got); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("Update of a deleted user = %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("SoftDelete", func(t *testing.T) {
+		repo := newRepo(t)
+		user := fixture("erin")
+		mustCreate(t, repo, user)
+
+		if err := repo.Delete(ctx, user.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repo.FindByID(ctx, user.ID); err == nil {
+			t.Fatal("FindByID found a soft-deleted user")
+		}
+		if _, err := repo.FindByEmail(ctx, "erin@example.com"); err == nil {
+			t.Fatal("FindByEmail found a soft-deleted user")
+		}
+
+		active, err := repo.List(ctx, repository.ListOptions{})
+		if err != nil || len(active) != 0 {
+			t.Fatalf("List = %d users, %v; want 0", len(active), err)
+		}
+		deleted, err := repo.List(ctx, repository.ListOptions{OnlyDeleted: true})
+		if err != nil || len(deleted) != 1 || deleted[0].ID != user.ID {
+			t.Fatalf("List(OnlyDeleted) = %v, %v", deleted, err)
+		}
+		if !deleted[0].DeletedAt.Valid {
+			t.Fatal("deleted user has no DeletedAt")
+		}
+		before, err := repo.List(ctx, repository.ListOptions{OnlyDeleted: true, DeletedBefore: time.Now().Add(-time.Hour)})
+		if err != nil || len(before) != 0 {
+			t.Fatalf("List(DeletedBefore) = %d users, %v; want 0", len(before), err)
+		}
+		if ok, _ := repo.EmailExists(ctx, "erin@example.com"); ok {
+			t.Fatal("EmailExists reports a soft-deleted user")
+		}
+		if err := repo.Create(ctx, fixture("erin")); err == nil {
+			t.Fatal("soft-deleted user no longer reserves its email and username")
+		}
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		repo := newRepo(t)
+		user := fixture("rita")
+		mustCreate(t, repo, user)
+
+		if _, err := repo.FindDeletedByEmail(ctx, "rita@example.com"); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("FindDeletedByEmail of an active user = %v, want ErrUserNotFound", err)
+		}
+		if err := repo.Delete(ctx, user.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		deleted, err := repo.FindDeletedByEmail(ctx, "RITA@example.com")
+		if err != nil || deleted.ID != user.ID || !deleted.DeletedAt.Valid {
+			t.Fatalf("FindDeletedByEmail = %+v, %v", deleted, err)
+		}
+
+		if err := repo.Restore(ctx, deleted); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		if deleted.DeletedAt.Valid || deleted.Version != user.Version+1 {
+			t.Fatalf("restored user has DeletedAt %v, version %d", deleted.DeletedAt, deleted.Version)
+		}
+		got, err := repo.FindByEmail(ctx, "rita@example.com")
+		if err != nil || got.Version != deleted.Version {
+			t.Fatalf("FindByEmail after Restore = %+v, %v", got, err)
+		}
+		if err := repo.Restore(ctx, got); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("Restore of an active user = %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("HardDelete", func(t *testing.T) {
+		repo := newRepo(t)
+		user := fixture("frank")
+		mustCreate(t, repo, user)
+
+		if err := repo.HardDelete(ctx, user.ID); err != nil {
+			t.Fatalf("HardDelete: %v", err)
+		}
+		all, err := repo.List(ctx, repository.ListOptions{IncludeDeleted: true})
+		if err != nil || len(all) != 0 {
+			t.Fatalf("List(IncludeDeleted) = %d users, %v; want 0", len(all), err)
+		}
+		if ok, _ := repo.EmailExists(ctx, "frank@example.com"); ok {
+			t.Fatal("email still reserved after hard delete")
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, name := range []string{"gina", "hank", "ivan"} {
+			mustCreate(t, repo, fixture(name))
+		}
+		admin := fixture("judy")
+		admin.Role = models.RoleAdmin
+		mustCreate(t, repo, admin)
+
+		all, err := repo.List(ctx, repository.ListOptions{})
+		if err != nil || len(all) != 4 {
+			t.Fatalf("List = %d users, %v; want 4", len(all), err)
+		}
+		for i := 1; i < len(all); i++ {
+			if all[i-1].ID >= all[i].ID {
+				t.Fatal("List is not ordered by ID")
+			}
+		}
+		admins, err := repo.List(ctx, repository.ListOptions{Role: models.RoleAdmin})
+		if err != nil || len(admins) != 1 || admins[0].Username != "judy" {
+			t.Fatalf("List(Role) = %v, %v", admins, err)
+		}
+		found, err := repo.List(ctx, repository.ListOptions{Search: "HANK"})
+		if err != nil || len(found) != 1 || found[0].Username != "hank" {
+			t.Fatalf("List(Search) = %v, %v", found, err)
+		}
+		page, err := repo.List(ctx, repository.ListOptions{Limit: 2, Offset: 1})
+		if err != nil || len(page) != 2 || page[0].Username != "hank" {
+			t.Fatalf("List(Limit, Offset) = %v, %v", page, err)
+		}
+	})
+}
+
+func fixture(username string) *models.User {
+	return &models.User{
+		FullName: "User " + username,
+		Username: username,
+		Email:    username + "@example.com",
+		Role:     models.RoleUser,
+		Password: "$2a$04$placeholderhashplaceholderhashplaceholderhashpl",
+	}
+}
+
+func mustCreate(t *testing.T, repo repository.UserRepository, user *models.User) {
+	t.Helper()
+	if err := repo.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create(%s): %v", user.Username, err)
+	}
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vayura/internal/models"
+)
+
+// SessionRepository defines the interface for login session storage
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	FindByID(ctx context.Context, id string) (*models.Session, error)
+	// RevokeAll revokes every active session of the user and returns how many were revoked
+	RevokeAll(ctx context.Context, userID uint, at time.Time) (int64, error)
+	// ListByUser returns every session of the user, revoked and expired ones included, oldest first
+	ListByUser(ctx context.Context, userID uint) ([]models.Session, error)
+}
+
+// EmailChangeRepository defines the interface for email change request storage
+type EmailChangeRepository interface {
+	Create(ctx context.Context, change *models.EmailChange) error
+	FindByConfirmTokenHash(ctx context.Context, hash string) (*models.EmailChange, error)
+	FindByCancelTokenHash(ctx context.Context, hash string) (*models.EmailChange, error)
+	// Latest returns the most recent request of the user, whatever its state
+	Latest(ctx context.Context, userID uint) (*models.EmailChange, error)
+	Update(ctx context.Context, change *models.EmailChange) error
+	// CancelPending cancels the unconfirmed requests of the user
+	CancelPending(ctx context.Context, userID uint, at time.Time) error
+	// ListByUser returns every request of the user, oldest first
+	ListByUser(ctx context.Context, userID uint) ([]models.EmailChange, error)
+}
+
+// PhoneVerificationRepository defines the interface for phone verification code storage
+type PhoneVerificationRepository interface {
+	Create(ctx context.Context, verification *models.PhoneVerification) error
+	// Latest returns the most recent code sent to the user, whatever its state
+	Latest(ctx context.Context, userID uint) (*models.PhoneVerification, error)
+	// UseAttempt counts a guess against the code and reports false, counting nothing, once max guesses were made
+	UseAttempt(ctx context.Context, id uint, max int) (bool, error)
+	Update(ctx context.Context, verification *models.PhoneVerification) error
+	// ListByUser returns every code sent to the user, oldest first
+	ListByUser(ctx context.Context, userID uint) ([]models.PhoneVerification, error)
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"gorm.io/gorm"
+)
+
+// sessionRepository implements SessionRepository interface
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	return translateError(conn(ctx, r.db).Create(session).Error, nil)
+}
+
+func (r *sessionRepository) FindByID(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	err := conn(ctx, r.db).Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, translateError(err, ErrSessionNotFound)
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) RevokeAll(ctx context.Context, userID uint, at time.Time) (int64, error) {
+	res := conn(ctx, r.db).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at)
+	return res.RowsAffected, translateError(res.Error, nil)
+}
+
+func (r *sessionRepository) ListByUser(ctx context.Context, userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := conn(ctx, r.db).Where("user_id = ?", userID).Order("created_at, id").Find(&sessions).Error
+	return sessions, translateError(err, nil)
+}
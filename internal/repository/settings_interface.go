@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+// SettingsRepository defines the interface for per-user setting overrides, stored as JSON text by key
+type SettingsRepository interface {
+	Overrides(ctx context.Context, userID uint) (map[string]string, error)
+	// Save writes the values in set and removes the overrides of reset in one transaction
+	Save(ctx context.Context, userID uint, set map[string]string, reset []string) error
+}
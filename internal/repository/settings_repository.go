@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// settingsRepository implements SettingsRepository interface
+type settingsRepository struct {
+	db *gorm.DB
+}
+
+// NewSettingsRepository creates a new settings repository
+func NewSettingsRepository(db *gorm.DB) SettingsRepository {
+	return &settingsRepository{db: db}
+}
+
+func (r *settingsRepository) Overrides(ctx context.Context, userID uint) (map[string]string, error) {
+	var rows []models.UserSetting
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return nil, translateError(err, nil)
+	}
+	overrides := make(map[string]string, len(rows))
+	for _, row := range rows {
+		overrides[row.Key] = row.Value
+	}
+	return overrides, nil
+}
+
+func (r *settingsRepository) Save(ctx context.Context, userID uint, set map[string]string, reset []string) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if len(reset) > 0 {
+			if err := tx.Where("user_id = ? AND key IN ?", userID, reset).Delete(&models.UserSetting{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(set) == 0 {
+			return nil
+		}
+		now := time.Now()
+		rows := make([]models.UserSetting, 0, len(set))
+		for key, value := range set {
+			rows = append(rows, models.UserSetting{UserID: userID, Key: key, Value: value, UpdatedAt: now})
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+		}).Create(&rows).Error
+	})
+	return translateError(err, nil)
+}
@@ -0,0 +1,11 @@
+package repository
+
+import "context"
+
+// TxManager runs a unit of work across repositories in one transaction
+type TxManager interface {
+	// WithinTx runs fn in a transaction carried by the ctx it receives; repositories called with
+	// that ctx join the transaction. The transaction is rolled back when fn returns an error or
+	// panics. Nested calls run in a savepoint, so a failing inner call only undoes its own work.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
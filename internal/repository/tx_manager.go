@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// txKey is the context key of the active *gorm.DB transaction
+type txKey struct{}
+
+// txManager implements TxManager with GORM transactions; nested calls use savepoints
+type txManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager creates a transaction manager for db
+func NewTxManager(db *gorm.DB) TxManager {
+	return &txManager{db: db}
+}
+
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	var fnErr error
+	err := conn(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		fnErr = fn(context.WithValue(ctx, txKey{}, tx))
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	// only begin, savepoint and commit failures are left
+	return translateError(err, nil)
+}
+
+// conn returns the transaction active in ctx, or db when there is none, bound to ctx
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,19 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/internal/repository/repotest"
+)
+
+func TestMemoryTxManager(t *testing.T) {
+	repotest.RunTxManagerSuite(t, func(t *testing.T) (repository.TxManager, repository.UserRepository) {
+		repo := repository.NewMemoryUserRepository()
+		return repository.NewMemoryTxManager(repo), repo
+	})
+}
+
+func TestTxManager(t *testing.T) {
+	repotest.RunTxManagerBackends(t)
+}
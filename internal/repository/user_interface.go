@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/vayura/internal/models"
 )
@@ -12,8 +13,36 @@ type UserRepository interface {
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	FindByID(ctx context.Context, id uint) (*models.User, error)
 	FindByUsername(ctx context.Context, username string) (*models.User, error)
-	Update(ctx context.Context, user *models.User) error
+	// FindByPhone returns the oldest active user with the given E.164 phone number
+	FindByPhone(ctx context.Context, phone string) (*models.User, error)
+	// Update writes the given columns of user (all of them when none are given) if the stored
+	// version still equals user.Version and then increments it. It returns pkg.ErrVersionMismatch
+	// when the row was changed in the meantime.
+	Update(ctx context.Context, user *models.User, columns ...string) error
 	Delete(ctx context.Context, id uint) error
+	// FindDeletedByEmail returns the soft-deleted user with email, for restoring it
+	FindDeletedByEmail(ctx context.Context, email string) (*models.User, error)
+	// Restore undoes the soft delete of user and increments its version
+	Restore(ctx context.Context, user *models.User) error
 	EmailExists(ctx context.Context, email string) (bool, error)
 	UsernameExists(ctx context.Context, username string) (bool, error)
+	List(ctx context.Context, opts ListOptions) ([]models.User, error)
+	HardDelete(ctx context.Context, id uint) error
+}
+
+// ListOptions filters and paginates user listings
+type ListOptions struct {
+	Role           string
+	Search         string    // matches username, email or full name
+	OnlyDeleted    bool      // list soft-deleted users only
+	DeletedBefore  time.Time // with OnlyDeleted, only users deleted before this time
+	IncludeDeleted bool
+	Limit          int
+	Offset         int
+}
+
+// UserAnonymizer is implemented by repositories whose records must outlive a purged user; it
+// strips the user's personal data from them in the purge transaction, before the row is deleted
+type UserAnonymizer interface {
+	AnonymizeUser(ctx context.Context, userID uint) error
 }
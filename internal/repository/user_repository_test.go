@@ -0,0 +1,18 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/internal/repository/repotest"
+)
+
+func TestMemoryUserRepository(t *testing.T) {
+	repotest.RunUserRepositorySuite(t, func(t *testing.T) repository.UserRepository {
+		return repository.NewMemoryUserRepository()
+	})
+}
+
+func TestUserRepository(t *testing.T) {
+	repotest.RunUserRepositoryBackends(t)
+}
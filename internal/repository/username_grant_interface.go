@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vayura/internal/models"
+)
+
+// UsernameGrantRepository defines the interface for admin grants of reserved usernames
+type UsernameGrantRepository interface {
+	// Find returns the grant of a username skeleton, ErrUsernameGrantNotFound if there is none
+	Find(ctx context.Context, skeleton string) (*models.UsernameGrant, error)
+	// Save adds grant, replacing a grant of the same skeleton to another user
+	Save(ctx context.Context, grant *models.UsernameGrant) error
+	// Delete removes the grant of a username skeleton, ErrUsernameGrantNotFound if there is none
+	Delete(ctx context.Context, skeleton string) error
+	// List returns every grant ordered by username
+	List(ctx context.Context) ([]models.UsernameGrant, error)
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vayura/internal/models"
+	"gorm.io/gorm"
+)
+
+// usernameGrantRepository implements UsernameGrantRepository interface
+type usernameGrantRepository struct {
+	db *gorm.DB
+}
+
+// NewUsernameGrantRepository creates a new username grant repository
+func NewUsernameGrantRepository(db *gorm.DB) UsernameGrantRepository {
+	return &usernameGrantRepository{db: db}
+}
+
+func (r *usernameGrantRepository) Find(ctx context.Context, skeleton string) (*models.UsernameGrant, error) {
+	var grant models.UsernameGrant
+	err := conn(ctx, r.db).Where("username_skeleton = ?", skeleton).First(&grant).Error
+	if err != nil {
+		return nil, translateError(err, ErrUsernameGrantNotFound)
+	}
+	return &grant, nil
+}
+
+func (r *usernameGrantRepository) Save(ctx context.Context, grant *models.UsernameGrant) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("username_skeleton = ?", grant.UsernameSkeleton).Delete(&models.UsernameGrant{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(grant).Error
+	})
+	return translateError(err, nil)
+}
+
+func (r *usernameGrantRepository) Delete(ctx context.Context, skeleton string) error {
+	result := conn(ctx, r.db).Where("username_skeleton = ?", skeleton).Delete(&models.UsernameGrant{})
+	if result.Error != nil {
+		return translateError(result.Error, nil)
+	}
+	if result.RowsAffected == 0 {
+		return ErrUsernameGrantNotFound
+	}
+	return nil
+}
+
+func (r *usernameGrantRepository) List(ctx context.Context) ([]models.UsernameGrant, error) {
+	var grants []models.UsernameGrant
+	err := conn(ctx, r.db).Order("username").Find(&grants).Error
+	return grants, translateError(err, nil)
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/vayura/config"
+)
+
+// WorkerFunc is a background job that runs until its context is cancelled
+type WorkerFunc func(ctx context.Context)
+
+// CloserFunc releases a resource (DB pool, files, ...) during shutdown
+type CloserFunc func(ctx context.Context) error
+
+type worker struct {
+	name string
+	fn   WorkerFunc
+}
+
+type closer struct {
+	name string
+	fn   CloserFunc
+}
+
+// Server wraps http.Server with readiness, background workers and an ordered shutdown
+type Server struct {
+	cfg     config.ServerConfig
+	http    *http.Server
+	ready   atomic.Bool
+	workers []worker
+	closers []closer
+}
+
+// New creates a new server; the handler is attached later with SetHandler
+func New(cfg config.ServerConfig) *Server {
+	return &Server{
+		cfg: cfg,
+		http: &http.Server{
+			Addr:              fmt.Sprintf(":%s", cfg.Port),
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		},
+	}
+}
+
+// SetHandler replaces the HTTP handler; it must be called before Run
+func (s *Server) SetHandler(handler http.Handler) {
+	s.http.Handler = handler
+}
+
+// Ready reports whether the server should receive new traffic
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// Go registers a background worker that is started by Run and stopped on shutdown
+func (s *Server) Go(name string, fn WorkerFunc) {
+	s.workers = append(s.workers, worker{name: name, fn: fn})
+}
+
+// OnShutdown registers a closer; closers run in registration order after requests are drained
+func (s *Server) OnShutdown(name string, fn CloserFunc) {
+	s.closers = append(s.closers, closer{name: name, fn: fn})
+}
+
+// Run serves HTTP until SIGINT/SIGTERM is received or ctx is done, then shuts down gracefully
+func (s *Server) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Bind before reporting ready, so a port in use fails the start instead of a later probe
+	ln, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.http.Addr, err)
+	}
+
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+
+	var wg sync.WaitGroup
+	for _, w := range s.workers {
+		wg.Add(1)
+		go func(w worker) {
+			defer wg.Done()
+			log.Printf("⚙️  Worker %s started", w.name)
+			w.fn(workerCtx)
+			log.Printf("⚙️  Worker %s stopped", w.name)
+		}(w)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("🚀 Server starting on port %s", s.cfg.Port)
+		if err := s.http.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+	s.ready.Store(true)
+
+	var runErr error
+	select {
+	case err := <-serveErr:
+		runErr = err
+		log.Printf("❌ Server stopped unexpectedly: %v", err)
+	case <-ctx.Done():
+		log.Println("🛑 Shutdown signal received")
+	}
+
+	s.ready.Store(false)
+	log.Println("🛑 Readiness set to failing")
+	if runErr == nil && s.cfg.DrainDelay > 0 {
+		log.Printf("🛑 Waiting %s for load balancers to stop routing traffic", s.cfg.DrainDelay)
+		s.waitDrain()
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	log.Printf("🛑 Draining in-flight requests (deadline %s)", s.cfg.ShutdownTimeout)
+	if err := s.http.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️  HTTP shutdown incomplete: %v", err)
+		runErr = errors.Join(runErr, err)
+	} else {
+		log.Println("✅ HTTP server drained")
+	}
+
+	log.Println("🛑 Stopping background workers")
+	cancelWorkers()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Println("✅ Background workers stopped")
+	case <-shutdownCtx.Done():
+		log.Println("⚠️  Background workers did not stop before the deadline")
+		runErr = errors.Join(runErr, shutdownCtx.Err())
+	}
+
+	for _, c := range s.closers {
+		if err := c.fn(shutdownCtx); err != nil {
+			log.Printf("⚠️  Failed to close %s: %v", c.name, err)
+			runErr = errors.Join(runErr, err)
+			continue
+		}
+		log.Printf("✅ Closed %s", c.name)
+	}
+
+	log.Println("👋 Shutdown complete")
+	return runErr
+}
+
+// waitDrain waits DrainDelay, or less when a second signal asks to stop right away
+func (s *Server) waitDrain() {
+	again := make(chan os.Signal, 1)
+	signal.Notify(again, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(again)
+
+	timer := time.NewTimer(s.cfg.DrainDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-again:
+		log.Println("🛑 Second signal received, skipping the drain delay")
+	}
+}
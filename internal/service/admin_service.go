@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/phone"
+)
+
+// adminService implements AdminService interface
+type adminService struct {
+	userRepo       repository.UserRepository
+	txManager      repository.TxManager
+	authService    AuthService
+	sessionService SessionService
+	purgeService   PurgeService
+	auditService   AuditService
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(userRepo repository.UserRepository, txManager repository.TxManager, authService AuthService, sessionService SessionService, purgeService PurgeService, auditService AuditService) AdminService {
+	return &adminService{
+		userRepo:       userRepo,
+		txManager:      txManager,
+		authService:    authService,
+		sessionService: sessionService,
+		purgeService:   purgeService,
+		auditService:   auditService,
+	}
+}
+
+// FindUser resolves a user by numeric ID, email, phone number (with a leading +) or username
+func (s *adminService) FindUser(ctx context.Context, ref string) (*models.User, error) {
+	var (
+		user *models.User
+		err  error
+	)
+	if id, convErr := strconv.ParseUint(ref, 10, 64); convErr == nil {
+		user, err = s.userRepo.FindByID(ctx, uint(id))
+	} else if strings.HasPrefix(ref, "+") {
+		number, normErr := phone.Normalize(ref)
+		if normErr != nil {
+			return nil, repository.ErrUserNotFound
+		}
+		user, err = s.userRepo.FindByPhone(ctx, number)
+	} else if strings.Contains(ref, "@") {
+		user, err = s.userRepo.FindByEmail(ctx, ref)
+	} else {
+		user, err = s.userRepo.FindByUsername(ctx, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *adminService) CreateUser(ctx context.Context, req RegisterRequest) (*models.User, error) {
+	if req.Role == "" {
+		req.Role = models.RoleUser
+	}
+	if !models.IsValidRole(req.Role) {
+		return nil, pkg.ErrInvalidRole
+	}
+	return s.authService.Register(ctx, req)
+}
+
+func (s *adminService) ListUsers(ctx context.Context, opts repository.ListOptions) ([]models.User, error) {
+	return s.userRepo.List(ctx, opts)
+}
+
+func (s *adminService) SetRole(ctx context.Context, userID uint, role string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, pkg.ErrInvalidRole
+	}
+	return s.updateUser(ctx, userID, models.AuditRoleChanged, func(user *models.User) {
+		user.Role = role
+	}, "role")
+}
+
+func (s *adminService) ResetPassword(ctx context.Context, userID uint, password string) error {
+	if len(password) < 8 {
+		return &pkg.ValidationError{Field: "password", Rule: "min", Param: "8", Message: "password must be at least 8 characters"}
+	}
+	// hash outside the transaction, bcrypt is slow on purpose
+	hashed := &models.User{}
+	if err := hashed.HashPassword(password); err != nil {
+		return err
+	}
+
+	// Sign out every session along with the old password
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := s.updateUser(ctx, userID, models.AuditPasswordReset, func(user *models.User) {
+			user.Password = hashed.Password
+		}, "password")
+		if err != nil {
+			return err
+		}
+		return s.sessionService.RevokeAll(ctx, userID)
+	})
+}
+
+func (s *adminService) Suspend(ctx context.Context, userID uint, suspended bool) (*models.User, error) {
+	action := models.AuditUnsuspended
+	if suspended {
+		action = models.AuditSuspended
+	}
+	// A suspension also signs out every session, so refresh tokens cannot outlive it
+	var user *models.User
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.updateUser(ctx, userID, action, func(user *models.User) {
+			if suspended && user.SuspendedAt == nil {
+				now := time.Now()
+				user.SuspendedAt = &now
+			} else if !suspended {
+				user.SuspendedAt = nil
+			}
+		}, "suspended_at")
+		if err != nil || !suspended {
+			return err
+		}
+		return s.sessionService.RevokeAll(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeleteUser schedules the account for purge like a self-service deletion, or purges it right away when hard is set
+func (s *adminService) DeleteUser(ctx context.Context, userID uint, hard bool) error {
+	if hard {
+		return s.purgeService.Purge(ctx, userID)
+	}
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Delete(ctx, userID); err != nil {
+			return err
+		}
+		if err := s.auditService.Record(ctx, models.AuditDeleted, userID, nil); err != nil {
+			return err
+		}
+		return s.sessionService.RevokeAll(ctx, userID)
+	})
+}
+
+// PurgeDeleted permanently removes users soft-deleted before the given time and returns how many were removed
+func (s *adminService) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	return s.purgeService.PurgeDeleted(ctx, before)
+}
+
+// updateUser loads the user, applies change and saves the changed columns in one transaction,
+// recording action with the diff in the audit log
+func (s *adminService) updateUser(ctx context.Context, userID uint, action string, change func(user *models.User), columns ...string) (*models.User, error) {
+	var user *models.User
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.userRepo.FindByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		before := *user
+		change(user)
+		if err := s.userRepo.Update(ctx, user, columns...); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, action, userID, models.DiffUsers(&before, user))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
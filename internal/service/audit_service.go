@@ -0,0 +1,470 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vayura/config"
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/fieldcrypt"
+)
+
+// Audit listing limits
+const (
+	DefaultAuditLimit = 50
+	MaxAuditLimit     = 200
+)
+
+// auditArchiveBatch is the number of entries written to one archive file
+const auditArchiveBatch = 1000
+
+// Domains of the encrypted fields of archived entries; the diff is sealed like audit_entries.changes
+const (
+	auditArchiveChangesDomain = "audit_entries.changes"
+	auditArchiveClientDomain  = "audit_entries.client"
+)
+
+// auditVerifyBatch is the number of entries read at a time while walking the chain
+const auditVerifyBatch = 1000
+
+// AuditVerification is the outcome of walking the audit chain
+type AuditVerification struct {
+	AnchorID      uint        `json:"anchor_checkpoint_id,omitempty"` // checkpoint the walk started from
+	AnchorEntryID uint        `json:"anchor_entry_id,omitempty"`
+	FromID        uint        `json:"from_id,omitempty"`    // first entry checked
+	ThroughID     uint        `json:"through_id,omitempty"` // last entry checked
+	Hash          string      `json:"hash,omitempty"`       // hash of the last entry checked
+	Entries       int         `json:"entries"`
+	Redacted      int         `json:"redacted"`                 // entries whose personal data was cleared by a purge
+	Checkpoints   int         `json:"checkpoints"`              // checkpoints with a valid signature
+	Unverifiable  int         `json:"unverifiable_checkpoints"` // signed with keys no longer in the key set
+	Break         *AuditBreak `json:"break,omitempty"`
+}
+
+// AuditBreak is the first place where the audit chain fails verification
+type AuditBreak struct {
+	EntryID      uint   `json:"entry_id,omitempty"`
+	CheckpointID uint   `json:"checkpoint_id,omitempty"`
+	Reason       string `json:"reason"`
+}
+
+func (b *AuditBreak) String() string {
+	if b.EntryID != 0 {
+		return fmt.Sprintf("entry %d: %s", b.EntryID, b.Reason)
+	}
+	return fmt.Sprintf("checkpoint %d: %s", b.CheckpointID, b.Reason)
+}
+
+// auditService implements AuditService interface
+type auditService struct {
+	auditRepo repository.AuditRepository
+	cfg       config.AuditConfig
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(auditRepo repository.AuditRepository, cfg config.AuditConfig) AuditService {
+	return &auditService{auditRepo: auditRepo, cfg: cfg}
+}
+
+func (s *auditService) Record(ctx context.Context, action string, targetID uint, changes models.AuditChanges) error {
+	if len(changes) == 0 {
+		changes = nil
+	}
+	actor := pkg.ActorFromContext(ctx)
+	entry := &models.AuditEntry{
+		Source:    actor.Source,
+		Action:    action,
+		Changes:   changes,
+		IP:        actor.IP,
+		UserAgent: actor.UserAgent,
+		RequestID: actor.RequestID,
+	}
+	if actor.UserID != 0 {
+		entry.ActorID = &actor.UserID
+	}
+	if targetID != 0 {
+		entry.TargetID = &targetID
+	}
+	return s.auditRepo.Create(ctx, entry)
+}
+
+func (s *auditService) List(ctx context.Context, filter repository.AuditFilter) ([]models.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditLimit
+	}
+	if filter.Limit > MaxAuditLimit {
+		filter.Limit = MaxAuditLimit
+	}
+	return s.auditRepo.List(ctx, filter)
+}
+
+func (s *auditService) Archive(ctx context.Context) (int, error) {
+	if s.cfg.Retention <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().Add(-s.cfg.Retention)
+
+	// Never archive a broken chain, since the checkpoints below would vouch for it
+	if _, err := s.Checkpoint(ctx); err != nil {
+		return 0, err
+	}
+
+	archived := 0
+	for {
+		entries, err := s.auditRepo.ListAfter(ctx, 0, auditArchiveBatch)
+		if err != nil {
+			return archived, err
+		}
+		// Archive the oldest entries up to the first one still within retention, so the
+		// database always keeps an unbroken run of the latest entries
+		n := 0
+		for n < len(entries) && entries[n].CreatedAt.Before(cutoff) {
+			n++
+		}
+		if n == 0 {
+			return archived, nil
+		}
+		entries = entries[:n]
+
+		if err := s.writeArchive(entries); err != nil {
+			return archived, err
+		}
+		// The first entry left in the database links to the last archived one; the checkpoint
+		// keeps its hash to verify that link
+		last := entries[n-1]
+		if _, err := s.signCheckpoint(ctx, last.ID, last.Hash); err != nil {
+			return archived, err
+		}
+		if _, err := s.auditRepo.DeleteThrough(ctx, entries[n-1].ID); err != nil {
+			return archived, err
+		}
+		archived += n
+	}
+}
+
+func (s *auditService) Verify(ctx context.Context, full bool) (*AuditVerification, error) {
+	result := &AuditVerification{}
+
+	// Read the head before the entries, so entries appended during the walk do not count as missing
+	headID, headHash, err := s.auditRepo.ChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Checkpoints with a valid signature, by entry
+	checkpoints, err := s.auditRepo.ListCheckpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	signed := make(map[uint]models.AuditCheckpoint)
+	var latest models.AuditCheckpoint
+	for _, cp := range checkpoints {
+		signature, err := hex.DecodeString(cp.Signature)
+		if err == nil {
+			err = pkg.VerifySignature(cp.KeyID, cp.Payload(), signature)
+		}
+		if errors.Is(err, pkg.ErrUnknownSigningKey) {
+			result.Unverifiable++
+			continue
+		}
+		if err != nil {
+			result.Break = &AuditBreak{CheckpointID: cp.ID, Reason: "signature does not verify"}
+			return result, nil
+		}
+		if other, ok := signed[cp.EntryID]; ok && other.Hash != cp.Hash {
+			result.Break = &AuditBreak{CheckpointID: cp.ID, Reason: fmt.Sprintf("conflicts with checkpoint %d", other.ID)}
+			return result, nil
+		}
+		signed[cp.EntryID] = cp
+		result.Checkpoints++
+		if cp.EntryID >= latest.EntryID {
+			latest = cp
+		}
+	}
+
+	first, err := s.auditRepo.ListAfter(ctx, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// The walk starts at an anchor: the latest checkpoint, or for a full walk the checkpoint of the
+	// last archived entry (none while nothing was archived, the chain then starts with an empty hash)
+	var anchor models.AuditCheckpoint
+	switch {
+	case !full, len(first) == 0:
+		anchor = latest
+	default:
+		for entryID, cp := range signed {
+			if entryID < first[0].ID && entryID >= anchor.EntryID {
+				anchor = cp
+			}
+		}
+	}
+	result.AnchorID, result.AnchorEntryID = anchor.ID, anchor.EntryID
+
+	// A checkpointed entry may only be missing when it was archived, along with everything before it
+	if anchor.EntryID != 0 && len(first) > 0 && first[0].ID < anchor.EntryID {
+		around, err := s.auditRepo.ListAfter(ctx, anchor.EntryID-1, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(around) == 0 || around[0].ID != anchor.EntryID {
+			result.Break = &AuditBreak{EntryID: anchor.EntryID, Reason: fmt.Sprintf("entry signed by checkpoint %d is missing", anchor.ID)}
+			return result, nil
+		}
+	}
+
+	purged := purgedUsers{repo: s.auditRepo, known: map[uint]bool{}}
+	prev := anchor.Hash
+	afterID := anchor.EntryID
+	if afterID > 0 {
+		afterID-- // include the anchor entry itself
+	}
+	for {
+		entries, err := s.auditRepo.ListAfter(ctx, afterID, auditVerifyBatch)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			// The anchor entry was linked when its checkpoint was signed; it only has to match it
+			if e.ID != anchor.EntryID && e.PrevHash != prev {
+				result.Break = &AuditBreak{EntryID: e.ID, Reason: "does not link to the previous entry: entries were removed, inserted or reordered"}
+				return result, nil
+			}
+			ok, changesCleared, clientCleared := e.VerifyHash()
+			if !ok {
+				result.Break = &AuditBreak{EntryID: e.ID, Reason: "content does not match its hash: the entry was edited"}
+				return result, nil
+			}
+			// Only a purge clears personal data: the diff of entries about the purged user, and the
+			// client details of entries about or by them
+			redacted := changesCleared || clientCleared
+			if redacted {
+				cleared, err := purged.was(ctx, e.TargetID)
+				if err == nil && !cleared && !changesCleared {
+					cleared, err = purged.was(ctx, e.ActorID)
+				}
+				if err != nil {
+					return nil, err
+				}
+				if !cleared {
+					result.Break = &AuditBreak{EntryID: e.ID, Reason: "personal data was cleared but the user was not purged: the entry was edited"}
+					return result, nil
+				}
+			}
+			if cp, found := signed[e.ID]; found && cp.Hash != e.Hash {
+				result.Break = &AuditBreak{EntryID: e.ID, Reason: fmt.Sprintf("hash does not match checkpoint %d", cp.ID)}
+				return result, nil
+			}
+			if e.ID == headID && e.Hash != headHash {
+				result.Break = &AuditBreak{EntryID: e.ID, Reason: "hash does not match the chain head"}
+				return result, nil
+			}
+
+			if redacted {
+				result.Redacted++
+			}
+			if result.FromID == 0 {
+				result.FromID = e.ID
+			}
+			result.ThroughID, result.Hash = e.ID, e.Hash
+			result.Entries++
+			prev, afterID = e.Hash, e.ID
+		}
+		if len(entries) < auditVerifyBatch {
+			break
+		}
+	}
+
+	// The last entry appended must still be there, unless it was archived
+	if headID > result.ThroughID && !(headID == anchor.EntryID && headHash == anchor.Hash) {
+		result.Break = &AuditBreak{EntryID: headID, Reason: "last entry of the chain is missing: entries were removed from the end"}
+	}
+	return result, nil
+}
+
+// purgedUsers looks up whether users were purged, once per user
+type purgedUsers struct {
+	repo  repository.AuditRepository
+	known map[uint]bool
+}
+
+// was reports whether the user with id was purged; a nil id is no user and was never purged
+func (p purgedUsers) was(ctx context.Context, id *uint) (bool, error) {
+	if id == nil {
+		return false, nil
+	}
+	if purged, ok := p.known[*id]; ok {
+		return purged, nil
+	}
+	purged, err := p.repo.Purged(ctx, *id)
+	if err != nil {
+		return false, err
+	}
+	p.known[*id] = purged
+	return purged, nil
+}
+
+func (s *auditService) Checkpoint(ctx context.Context) (*models.AuditCheckpoint, error) {
+	result, err := s.Verify(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if result.Break != nil {
+		return nil, fmt.Errorf("audit chain broken at %s", result.Break)
+	}
+	if result.ThroughID <= result.AnchorEntryID {
+		return nil, nil
+	}
+	return s.signCheckpoint(ctx, result.ThroughID, result.Hash)
+}
+
+// signCheckpoint signs the hash of an entry with the server's signing key
+func (s *auditService) signCheckpoint(ctx context.Context, entryID uint, hash string) (*models.AuditCheckpoint, error) {
+	cp := &models.AuditCheckpoint{
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		EntryID:   entryID,
+		Hash:      hash,
+	}
+	kid, signature, err := pkg.Sign(cp.Payload())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign audit checkpoint: %w", err)
+	}
+	cp.KeyID, cp.Signature = kid, hex.EncodeToString(signature)
+	if err := s.auditRepo.CreateCheckpoint(ctx, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+func (s *auditService) Run(ctx context.Context) {
+	archiveTicker := time.NewTicker(s.cfg.ArchiveInterval)
+	defer archiveTicker.Stop()
+	checkpointTicker := time.NewTicker(s.cfg.CheckpointInterval)
+	defer checkpointTicker.Stop()
+
+	s.runArchive(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-archiveTicker.C:
+			s.runArchive(ctx)
+		case <-checkpointTicker.C:
+			cp, err := s.Checkpoint(ctx)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				log.Printf("❌ Audit checkpoint failed: %v", err)
+			case cp != nil:
+				log.Printf("🔏 Signed audit checkpoint %d at entry %d", cp.ID, cp.EntryID)
+			}
+		}
+	}
+}
+
+func (s *auditService) runArchive(ctx context.Context) {
+	archived, err := s.Archive(ctx)
+	switch {
+	case err != nil && ctx.Err() == nil:
+		log.Printf("❌ Audit archive failed: %v", err)
+	case archived > 0:
+		log.Printf("🗄️  Archived %d audit entries to %s", archived, s.cfg.ArchiveDir)
+	}
+}
+
+// archivedAuditEntry is a line of an archive file. The diff and the client details are encrypted
+// with the PII keyring; the hashes stay in the clear so the chain can be checked without it.
+type archivedAuditEntry struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ActorID   *uint     `json:"actor_id,omitempty"`
+	Source    string    `json:"source"`
+	TargetID  *uint     `json:"target_id,omitempty"`
+	Action    string    `json:"action"`
+	RequestID string    `json:"request_id,omitempty"`
+	Changes   string    `json:"changes,omitempty"` // models.AuditChanges
+	Client    string    `json:"client,omitempty"`  // [IP, user agent]
+
+	PrevHash    string `json:"prev_hash"`
+	ChangesHash string `json:"changes_hash"`
+	ClientHash  string `json:"client_hash"`
+	Hash        string `json:"hash"`
+}
+
+func newArchivedAuditEntry(e models.AuditEntry) (archivedAuditEntry, error) {
+	changes, err := fieldcrypt.EncryptValue(auditArchiveChangesDomain, e.Changes)
+	if err != nil {
+		return archivedAuditEntry{}, fmt.Errorf("audit entry %d: %w", e.ID, err)
+	}
+	var client string
+	if e.IP != "" || e.UserAgent != "" {
+		if client, err = fieldcrypt.EncryptValue(auditArchiveClientDomain, []string{e.IP, e.UserAgent}); err != nil {
+			return archivedAuditEntry{}, fmt.Errorf("audit entry %d: %w", e.ID, err)
+		}
+	}
+	return archivedAuditEntry{
+		ID:          e.ID,
+		CreatedAt:   e.CreatedAt,
+		ActorID:     e.ActorID,
+		Source:      e.Source,
+		TargetID:    e.TargetID,
+		Action:      e.Action,
+		RequestID:   e.RequestID,
+		Changes:     changes,
+		Client:      client,
+		PrevHash:    e.PrevHash,
+		ChangesHash: e.ChangesHash,
+		ClientHash:  e.ClientHash,
+		Hash:        e.Hash,
+	}, nil
+}
+
+// writeArchive writes entries as gzipped JSON Lines to audit-<first id>-<last id>.jsonl.gz; a retry
+// after a failed delete rewrites the same file
+func (s *auditService) writeArchive(entries []models.AuditEntry) error {
+	if err := os.MkdirAll(s.cfg.ArchiveDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create audit archive directory: %w", err)
+	}
+	name := fmt.Sprintf("audit-%010d-%010d.jsonl.gz", entries[0].ID, entries[len(entries)-1].ID)
+	path := filepath.Join(s.cfg.ArchiveDir, name)
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	for _, entry := range entries {
+		line, err := newArchivedAuditEntry(entry)
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
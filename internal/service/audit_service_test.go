@@ -0,0 +1,104 @@
+package service_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vayura/config"
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/internal/repository/repotest"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/fieldcrypt"
+)
+
+func TestAuditArchiveEncryptsPersonalData(t *testing.T) {
+	pkg.SetJWTSecret("audit-archive-test")
+	t.Cleanup(func() { pkg.SetJWTSecret("") })
+
+	s := newTestServices(t, repotest.OpenSQLite(t))
+	const ip, userAgent = "203.0.113.7", "archive-test/1.0"
+	ctx := pkg.WithActor(context.Background(), pkg.Actor{Source: pkg.SourceAPI, IP: ip, UserAgent: userAgent})
+	req := registerRequest("archived")
+	req.Phone = "+6281234567890"
+	if _, err := s.auth.Register(ctx, req); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	dir := t.TempDir()
+	audit := service.NewAuditService(repository.NewAuditRepository(s.db), config.AuditConfig{Retention: time.Nanosecond, ArchiveDir: dir})
+	time.Sleep(time.Millisecond)
+	archived, err := audit.Archive(context.Background())
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if archived == 0 {
+		t.Fatal("nothing archived")
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "audit-*.jsonl.gz"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("archive files %v (%v), want one", files, err)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gunzip: %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	for _, secret := range []string{req.Phone, ip, userAgent, req.FullName} {
+		if bytes.Contains(data, []byte(secret)) {
+			t.Fatalf("archive holds %q in the clear", secret)
+		}
+	}
+
+	// the keyring still opens the diff and the client details, and the hashes match them
+	var line struct {
+		Changes     string `json:"changes"`
+		Client      string `json:"client"`
+		ChangesHash string `json:"changes_hash"`
+		ClientHash  string `json:"client_hash"`
+	}
+	if err := json.Unmarshal(bytes.SplitN(data, []byte("\n"), 2)[0], &line); err != nil {
+		t.Fatalf("decode line: %v", err)
+	}
+	plain, err := fieldcrypt.Decrypt("audit_entries.changes", line.Changes)
+	if err != nil {
+		t.Fatalf("decrypt changes: %v", err)
+	}
+	var entry models.AuditEntry
+	if err := json.Unmarshal(plain, &entry.Changes); err != nil {
+		t.Fatalf("decode changes: %v", err)
+	}
+	if entry.Changes["phone"].New != req.Phone {
+		t.Fatalf("archived phone change %v, want %s", entry.Changes["phone"], req.Phone)
+	}
+	plain, err = fieldcrypt.Decrypt("audit_entries.client", line.Client)
+	if err != nil {
+		t.Fatalf("decrypt client: %v", err)
+	}
+	var client []string
+	if err := json.Unmarshal(plain, &client); err != nil || len(client) != 2 {
+		t.Fatalf("decode client %s: %v", plain, err)
+	}
+	entry.IP, entry.UserAgent = client[0], client[1]
+	entry.Chain("")
+	if entry.ChangesHash != line.ChangesHash || entry.ClientHash != line.ClientHash {
+		t.Fatal("decrypted diff or client details do not match the archived hashes")
+	}
+}
@@ -2,55 +2,64 @@ package service
 
 import (
 	"context"
+	"errors"
 	"regexp"
 	"time"
 
 	"github.com/vayura/internal/models"
 	"github.com/vayura/internal/repository"
 	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/identity"
+	"github.com/vayura/pkg/phone"
 )
 
 // authService implements AuthService interface
 type authService struct {
-	userRepo repository.UserRepository
+	userRepo       repository.UserRepository
+	txManager      repository.TxManager
+	auditService   AuditService
+	historyService ProfileHistoryService
+	usernamePolicy UsernamePolicy
+	deletionGrace  time.Duration
 }
 
-// NewAuthService creates a new authentication service
-func NewAuthService(userRepo repository.UserRepository) AuthService {
-	return &authService{userRepo: userRepo}
+// NewAuthService creates a new authentication service; deleted accounts can be restored
+// by logging in during deletionGrace
+func NewAuthService(userRepo repository.UserRepository, txManager repository.TxManager, auditService AuditService, historyService ProfileHistoryService, usernamePolicy UsernamePolicy, deletionGrace time.Duration) AuthService {
+	return &authService{userRepo: userRepo, txManager: txManager, auditService: auditService, historyService: historyService, usernamePolicy: usernamePolicy, deletionGrace: deletionGrace}
 }
 
 // RegisterRequest represents the registration request
 type RegisterRequest struct {
 	FullName string `json:"full_name" binding:"required,min=3"`
-	Username string `json:"username" binding:"required,min=3"`
+	Username string `json:"username" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=8"`
 	Phone    string `json:"phone"`
 	Role     string `json:"role"`
 	Gender   string `json:"gender"`
 	Birthday string `json:"birthday"` // format YYYY-MM-DD
+
+	GrantReserved bool `json:"-"` // take a reserved username and grant it to the new account; operators only
 }
 
 // LoginRequest represents the login request
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	Restore  bool   `json:"restore"` // restore the account if it is scheduled for deletion
 }
 
 func (s *authService) Register(ctx context.Context, req RegisterRequest) (*models.User, error) {
 	// Validation
-	if len(req.FullName) < 3 {
-		return nil, &pkg.ValidationError{Field: "full_name", Message: "full name must be at least 3 characters"}
-	}
-	if len(req.Username) < 3 {
-		return nil, &pkg.ValidationError{Field: "username", Message: "username must be at least 3 characters"}
+	if err := validateFullName(req.FullName); err != nil {
+		return nil, err
 	}
 	if !isValidEmail(req.Email) {
-		return nil, &pkg.ValidationError{Field: "email", Message: "invalid email format"}
+		return nil, &pkg.ValidationError{Field: "email", Rule: "email", Message: "invalid email format"}
 	}
 	if len(req.Password) < 8 {
-		return nil, &pkg.ValidationError{Field: "password", Message: "password must be at least 8 characters"}
+		return nil, &pkg.ValidationError{Field: "password", Rule: "min", Param: "8", Message: "password must be at least 8 characters"}
 	}
 
 	// Check if email already exists
@@ -62,22 +71,21 @@ func (s *authService) Register(ctx context.Context, req RegisterRequest) (*model
 		return nil, pkg.ErrEmailExists
 	}
 
-	// Check if username already exists
-	usernameExists, err := s.userRepo.UsernameExists(ctx, req.Username)
-	if err != nil {
+	// Check the username rules, taken usernames and reserved words
+	err = s.usernamePolicy.Check(ctx, 0, "", req.Username)
+	grantReserved := req.GrantReserved && errors.Is(err, identity.ErrUsernameReserved)
+	if err != nil && !grantReserved {
 		return nil, err
 	}
-	if usernameExists {
-		return nil, pkg.ErrUsernameExists
-	}
 
 	// Parse birthday if provided
-	var birth time.Time
-	if req.Birthday != "" {
-		birth, err = time.Parse("2006-01-02", req.Birthday)
-		if err != nil {
-			return nil, &pkg.ValidationError{Field: "birthday", Message: "invalid birthday format, use YYYY-MM-DD"}
-		}
+	birth, err := parseBirthday(req.Birthday)
+	if err != nil {
+		return nil, err
+	}
+	phoneNumber, err := normalizePhone(req.Phone)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create user
@@ -85,7 +93,7 @@ func (s *authService) Register(ctx context.Context, req RegisterRequest) (*model
 		FullName: req.FullName,
 		Username: req.Username,
 		Email:    req.Email,
-		Phone:    req.Phone,
+		Phone:    phoneNumber,
 		Role:     req.Role,
 		Gender:   req.Gender,
 		Birthday: birth,
@@ -96,27 +104,123 @@ func (s *authService) Register(ctx context.Context, req RegisterRequest) (*model
 		return nil, err
 	}
 
-	// Save user
-	if err := s.userRepo.Create(ctx, user); err != nil {
+	// Save user; anonymous API requests are self-registrations, anything else was created by an operator
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		action, actor := models.AuditCreated, pkg.ActorFromContext(ctx)
+		if actor.Source == pkg.SourceAPI && actor.UserID == 0 {
+			action, ctx = models.AuditRegistered, pkg.WithActorUser(ctx, user.ID)
+		}
+		if err := s.historyService.Record(ctx, user); err != nil {
+			return err
+		}
+		if err := s.auditService.Record(ctx, action, user.ID, models.DiffUsers(nil, user)); err != nil {
+			return err
+		}
+		if grantReserved {
+			_, err := s.usernamePolicy.Grant(ctx, user.Username, user.ID)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return user, nil
 }
 
+// Login blocks accounts scheduled for deletion; during the grace period the right password
+// together with req.Restore restores the account and logs in
 func (s *authService) Login(ctx context.Context, req LoginRequest) (*models.User, error) {
 	user, err := s.userRepo.FindByEmail(ctx, req.Email)
-	if err != nil {
+	if errors.Is(err, pkg.ErrNotFound) {
+		user, err = s.userRepo.FindDeletedByEmail(ctx, req.Email)
+	}
+	if errors.Is(err, pkg.ErrNotFound) {
 		return nil, pkg.ErrInvalidCredentials
 	}
+	if err != nil {
+		return nil, err
+	}
 
 	if !user.CheckPassword(req.Password) {
+		return nil, s.loginFailed(ctx, user, pkg.ErrInvalidCredentials)
+	}
+	if user.IsSuspended() {
+		return nil, s.loginFailed(ctx, user, pkg.ErrAccountSuspended)
+	}
+
+	// Past the grace period the account only waits for the purge worker
+	if user.DeletedAt.Valid && !time.Now().Before(PurgeAt(user, s.deletionGrace)) {
 		return nil, pkg.ErrInvalidCredentials
 	}
+	if user.DeletedAt.Valid && !req.Restore {
+		return nil, pkg.ErrPendingDeletion
+	}
 
+	ctx = pkg.WithActorUser(ctx, user.ID)
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if user.DeletedAt.Valid {
+			if err := s.userRepo.Restore(ctx, user); err != nil {
+				return err
+			}
+			if err := s.auditService.Record(ctx, models.AuditRestored, user.ID, nil); err != nil {
+				return err
+			}
+		}
+		return s.auditService.Record(ctx, models.AuditLogin, user.ID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
 	return user, nil
 }
 
+// loginFailed records a rejected login of an existing account and returns err
+func (s *authService) loginFailed(ctx context.Context, user *models.User, err error) error {
+	if recordErr := s.auditService.Record(ctx, models.AuditLoginFailed, user.ID, nil); recordErr != nil {
+		return recordErr
+	}
+	return err
+}
+
+// PurgeAt returns when a soft-deleted user is purged
+func PurgeAt(user *models.User, grace time.Duration) time.Time {
+	return user.DeletedAt.Time.Add(grace)
+}
+
+// Profile field rules shared by registration and profile updates
+
+func validateFullName(fullName string) error {
+	if len(fullName) < 3 {
+		return &pkg.ValidationError{Field: "full_name", Rule: "min", Param: "3", Message: "full name must be at least 3 characters"}
+	}
+	return nil
+}
+
+// normalizePhone returns phone in E.164; an empty string is no phone
+func normalizePhone(number string) (string, error) {
+	if number == "" {
+		return "", nil
+	}
+	return phone.Normalize(number)
+}
+
+// parseBirthday parses a YYYY-MM-DD birthday; an empty string is no birthday
+func parseBirthday(birthday string) (time.Time, error) {
+	if birthday == "" {
+		return time.Time{}, nil
+	}
+	birth, err := time.Parse("2006-01-02", birthday)
+	if err != nil {
+		return time.Time{}, &pkg.ValidationError{Field: "birthday", Rule: "date", Message: "invalid birthday format, use YYYY-MM-DD"}
+	}
+	return birth, nil
+}
+
 func isValidEmail(email string) bool {
 	regex := regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
 	return regex.MatchString(email)
@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/vayura/internal/repository/repotest"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+)
+
+// registerRequest returns a valid registration of username with a matching email
+func registerRequest(username string) service.RegisterRequest {
+	return service.RegisterRequest{
+		FullName: "Test " + username,
+		Username: username,
+		Email:    username + "@example.com",
+		Password: "password123",
+	}
+}
+
+// race runs fn for workers goroutines at once and returns how many succeeded; every other
+// call must fail with want
+func race(t *testing.T, workers int, want error, fn func(i int) error) int {
+	t.Helper()
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+		errs  = make(chan error, workers)
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs <- fn(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, want):
+			t.Fatalf("losing call = %v, want %v", err, want)
+		}
+	}
+	return succeeded
+}
+
+// TestConcurrentDuplicates races registrations and profile updates that pass the existence checks
+// together; the unique indexes must turn all but one into EMAIL_TAKEN or USERNAME_TAKEN, not a 500
+func TestConcurrentDuplicates(t *testing.T) {
+	const workers = 8
+	ctx := context.Background()
+
+	for _, backend := range repotest.Backends() {
+		t.Run(backend.Name, func(t *testing.T) {
+			t.Run("RegisterEmail", func(t *testing.T) {
+				s := newTestServices(t, backend.Open(t))
+				created := race(t, workers, pkg.ErrEmailExists, func(i int) error {
+					req := registerRequest(fmt.Sprintf("racer%d", i))
+					req.Email = "racer@example.com"
+					if i%2 == 1 {
+						req.Email = "Racer@Example.com"
+					}
+					_, err := s.auth.Register(ctx, req)
+					return err
+				})
+				if created != 1 {
+					t.Fatalf("%d registrations with the same email succeeded, want 1", created)
+				}
+			})
+
+			t.Run("RegisterUsername", func(t *testing.T) {
+				s := newTestServices(t, backend.Open(t))
+				created := race(t, workers, pkg.ErrUsernameExists, func(i int) error {
+					req := registerRequest("racer")
+					req.Email = fmt.Sprintf("racer%d@example.com", i)
+					if i%2 == 1 {
+						req.Username = "Racer"
+					}
+					_, err := s.auth.Register(ctx, req)
+					return err
+				})
+				if created != 1 {
+					t.Fatalf("%d registrations with the same username succeeded, want 1", created)
+				}
+			})
+
+			t.Run("UpdateProfileUsername", func(t *testing.T) {
+				s := newTestServices(t, backend.Open(t))
+				ids := make([]uint, workers)
+				for i := range ids {
+					user, err := s.auth.Register(ctx, registerRequest(fmt.Sprintf("owner%d", i)))
+					if err != nil {
+						t.Fatalf("Register: %v", err)
+					}
+					ids[i] = user.ID
+				}
+				renamed := race(t, workers, pkg.ErrUsernameExists, func(i int) error {
+					_, err := s.user.UpdateProfile(ctx, ids[i], 0, service.UpdateProfileRequest{FullName: "Test Owner", Username: "wanted"})
+					return err
+				})
+				if renamed != 1 {
+					t.Fatalf("%d users took the same username, want 1", renamed)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vayura/config"
+	"github.com/vayura/internal/mail"
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/identity"
+)
+
+// EmailChangeRequest represents a request to move the account to a new email address
+type EmailChangeRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// emailChangeService implements EmailChangeService interface
+type emailChangeService struct {
+	userRepo       repository.UserRepository
+	changeRepo     repository.EmailChangeRepository
+	txManager      repository.TxManager
+	sessionService SessionService
+	auditService   AuditService
+	historyService ProfileHistoryService
+	mailer         mail.Mailer
+	cfg            config.AccountConfig
+}
+
+// NewEmailChangeService creates a new email change service
+func NewEmailChangeService(userRepo repository.UserRepository, changeRepo repository.EmailChangeRepository, txManager repository.TxManager, sessionService SessionService, auditService AuditService, historyService ProfileHistoryService, mailer mail.Mailer, cfg config.AccountConfig) EmailChangeService {
+	return &emailChangeService{
+		userRepo:       userRepo,
+		changeRepo:     changeRepo,
+		txManager:      txManager,
+		sessionService: sessionService,
+		auditService:   auditService,
+		historyService: historyService,
+		mailer:         mailer,
+		cfg:            cfg,
+	}
+}
+
+// Request replaces any pending request of the user with a new one, mailing a confirmation link to
+// the new address and a cancel link to the current one. Requests are limited to one per cooldown.
+func (s *emailChangeService) Request(ctx context.Context, userID uint, req EmailChangeRequest) error {
+	newEmail, err := identity.NormalizeEmail(req.NewEmail)
+	if err != nil || !isValidEmail(newEmail) {
+		return &pkg.ValidationError{Field: "new_email", Rule: "email", Message: "invalid email format"}
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.CheckPassword(req.Password) {
+		return pkg.ErrWrongPassword
+	}
+	if newEmail == user.Email {
+		return pkg.ErrEmailUnchanged
+	}
+
+	now := time.Now()
+	latest, err := s.changeRepo.Latest(ctx, userID)
+	switch {
+	case errors.Is(err, repository.ErrEmailChangeNotFound):
+	case err != nil:
+		return err
+	case now.Sub(latest.CreatedAt) < s.cfg.EmailChangeCooldown:
+		return pkg.ErrEmailChangeWait
+	}
+
+	emailExists, err := s.userRepo.EmailExists(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+	if emailExists {
+		return pkg.ErrEmailExists
+	}
+
+	confirmToken, cancelToken := newToken(), newToken()
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.changeRepo.CancelPending(ctx, userID, now); err != nil {
+			return err
+		}
+		change := &models.EmailChange{
+			UserID:           userID,
+			OldEmail:         user.Email,
+			NewEmail:         newEmail,
+			ConfirmTokenHash: hashToken(confirmToken),
+			CancelTokenHash:  hashToken(cancelToken),
+			CreatedAt:        now,
+			ExpiresAt:        now.Add(s.cfg.EmailChangeTTL),
+		}
+		if err := s.changeRepo.Create(ctx, change); err != nil {
+			return err
+		}
+
+		// Mail inside the transaction so a request nobody was told about is rolled back
+		if err := s.mailer.Send(ctx, mail.Message{
+			To:      newEmail,
+			Subject: "Confirm your new email address",
+			Body: fmt.Sprintf("Hi %s,\n\nOpen this link to use %s for your account:\n\n%s\n\nThe link expires at %s. If you did not ask for this, ignore this message.\n",
+				user.FullName, newEmail, s.link("confirm", confirmToken), change.ExpiresAt.Format(time.RFC1123)),
+		}); err != nil {
+			return fmt.Errorf("failed to send confirmation email: %w", err)
+		}
+		if err := s.mailer.Send(ctx, mail.Message{
+			To:      user.Email,
+			Subject: "Your email address is about to change",
+			Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to change the email of your account to %s. Nothing changes until the new address is confirmed.\n\nIf this was not you, cancel the change with this link and change your password:\n\n%s\n",
+				user.FullName, newEmail, s.link("cancel", cancelToken)),
+		}); err != nil {
+			return fmt.Errorf("failed to send cancel email: %w", err)
+		}
+		return nil
+	})
+}
+
+// Confirm swaps the account email and signs out every session
+func (s *emailChangeService) Confirm(ctx context.Context, token string) error {
+	var change *models.EmailChange
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		change, err = s.pending(ctx, s.changeRepo.FindByConfirmTokenHash, token)
+		if err != nil {
+			return err
+		}
+
+		user, err := s.userRepo.FindByID(ctx, change.UserID)
+		if err != nil {
+			return err
+		}
+		// The address may have been registered by someone else since the request
+		emailExists, err := s.userRepo.EmailExists(ctx, change.NewEmail)
+		if err != nil {
+			return err
+		}
+		if emailExists {
+			return pkg.ErrEmailExists
+		}
+
+		before := *user
+		user.Email = change.NewEmail
+		if err := s.userRepo.Update(ctx, user, "email"); err != nil {
+			return err
+		}
+		// The emailed link proves the owner confirmed the change
+		ctx = pkg.WithActorUser(ctx, user.ID)
+		if err := s.historyService.Record(ctx, user); err != nil {
+			return err
+		}
+		if err := s.auditService.Record(ctx, models.AuditEmailChanged, user.ID, models.DiffUsers(&before, user)); err != nil {
+			return err
+		}
+		now := time.Now()
+		change.ConfirmedAt = &now
+		if err := s.changeRepo.Update(ctx, change); err != nil {
+			return err
+		}
+		return s.sessionService.RevokeAll(ctx, user.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The change is done, a failed notice must not undo it
+	if err := s.mailer.Send(ctx, mail.Message{
+		To:      change.OldEmail,
+		Subject: "Your email address was changed",
+		Body:    fmt.Sprintf("The email of your account was changed to %s and every session was signed out.\n", change.NewEmail),
+	}); err != nil {
+		log.Printf("⚠️  Failed to send email change notice to %s: %v", change.OldEmail, err)
+	}
+	return nil
+}
+
+func (s *emailChangeService) Cancel(ctx context.Context, token string) error {
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		change, err := s.pending(ctx, s.changeRepo.FindByCancelTokenHash, token)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		change.CancelledAt = &now
+		return s.changeRepo.Update(ctx, change)
+	})
+}
+
+// pending finds the request of token with find and checks it can still be confirmed or cancelled
+func (s *emailChangeService) pending(ctx context.Context, find func(context.Context, string) (*models.EmailChange, error), token string) (*models.EmailChange, error) {
+	if token == "" {
+		return nil, pkg.ErrEmailChangeToken
+	}
+	change, err := find(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if !change.IsPending(time.Now()) {
+		return nil, pkg.ErrEmailChangeToken
+	}
+	return change, nil
+}
+
+// link returns the web app URL that completes action with token
+func (s *emailChangeService) link(action, token string) string {
+	return strings.TrimRight(s.cfg.PublicURL, "/") + "/email-change/" + action + "?token=" + url.QueryEscape(token)
+}
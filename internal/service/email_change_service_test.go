@@ -0,0 +1,139 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository/repotest"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+)
+
+var tokenLink = regexp.MustCompile(`token=(\S+)`)
+
+// emailToken returns the token of the link in an email body
+func emailToken(t *testing.T, body string) string {
+	t.Helper()
+	m := tokenLink.FindStringSubmatch(body)
+	if m == nil {
+		t.Fatalf("no link in %q", body)
+	}
+	token, err := url.QueryUnescape(m[1])
+	if err != nil {
+		t.Fatalf("unescape token: %v", err)
+	}
+	return token
+}
+
+func TestEmailChange(t *testing.T) {
+	ctx := context.Background()
+
+	// request registers a user and asks to move it to newEmail, returning the user and the
+	// confirm and cancel tokens
+	request := func(t *testing.T, s *testServices, username, newEmail string) (*models.User, string, string) {
+		t.Helper()
+		user, err := s.auth.Register(ctx, registerRequest(username))
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		if err := s.emailChange.Request(ctx, user.ID, service.EmailChangeRequest{NewEmail: newEmail, Password: "password123"}); err != nil {
+			t.Fatalf("Request: %v", err)
+		}
+		return user, emailToken(t, s.mails.last(t, newEmail).Body), emailToken(t, s.mails.last(t, user.Email).Body)
+	}
+
+	t.Run("ConfirmSwapsAndRevokesSessions", func(t *testing.T) {
+		s := newTestServices(t, repotest.OpenSQLite(t))
+		user, confirm, _ := request(t, s, "mover", "moved@example.com")
+		session := s.startSession(t, user.ID)
+
+		if err := s.emailChange.Confirm(ctx, confirm); err != nil {
+			t.Fatalf("Confirm: %v", err)
+		}
+		got, err := s.user.GetProfile(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("GetProfile: %v", err)
+		}
+		if got.Email != "moved@example.com" {
+			t.Fatalf("email = %q, want moved@example.com", got.Email)
+		}
+		if err := s.session.Validate(ctx, user.ID, session); !errors.Is(err, pkg.ErrInvalidToken) {
+			t.Fatalf("Validate after confirm = %v, want ErrInvalidToken", err)
+		}
+		s.mails.last(t, user.Email) // the old address is told about the change
+
+		// a confirmed link cannot be used again, for either action
+		if err := s.emailChange.Confirm(ctx, confirm); !errors.Is(err, pkg.ErrEmailChangeToken) {
+			t.Fatalf("second Confirm = %v, want ErrEmailChangeToken", err)
+		}
+	})
+
+	t.Run("ExpiredToken", func(t *testing.T) {
+		s := newTestServices(t, repotest.OpenSQLite(t))
+		user, confirm, cancel := request(t, s, "sleeper", "late@example.com")
+		session := s.startSession(t, user.ID)
+		if err := s.db.Model(&models.EmailChange{}).Where("user_id = ?", user.ID).Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+			t.Fatalf("expire request: %v", err)
+		}
+
+		if err := s.emailChange.Confirm(ctx, confirm); !errors.Is(err, pkg.ErrEmailChangeToken) {
+			t.Fatalf("Confirm = %v, want ErrEmailChangeToken", err)
+		}
+		if err := s.emailChange.Cancel(ctx, cancel); !errors.Is(err, pkg.ErrEmailChangeToken) {
+			t.Fatalf("Cancel = %v, want ErrEmailChangeToken", err)
+		}
+		got, err := s.user.GetProfile(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("GetProfile: %v", err)
+		}
+		if got.Email != user.Email {
+			t.Fatalf("email = %q, want it unchanged", got.Email)
+		}
+		if err := s.session.Validate(ctx, user.ID, session); err != nil {
+			t.Fatalf("Validate = %v, want the session kept", err)
+		}
+	})
+
+	t.Run("CancelledToken", func(t *testing.T) {
+		s := newTestServices(t, repotest.OpenSQLite(t))
+		_, confirm, cancel := request(t, s, "doubter", "maybe@example.com")
+		if err := s.emailChange.Cancel(ctx, cancel); err != nil {
+			t.Fatalf("Cancel: %v", err)
+		}
+		if err := s.emailChange.Confirm(ctx, confirm); !errors.Is(err, pkg.ErrEmailChangeToken) {
+			t.Fatalf("Confirm after cancel = %v, want ErrEmailChangeToken", err)
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		s := newTestServices(t, repotest.OpenSQLite(t))
+		user, err := s.auth.Register(ctx, registerRequest("hasty"))
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		if _, err := s.auth.Register(ctx, registerRequest("taken")); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+
+		for _, tc := range []struct {
+			name string
+			req  service.EmailChangeRequest
+			want error
+		}{
+			{"WrongPassword", service.EmailChangeRequest{NewEmail: "other@example.com", Password: "wrong-password"}, pkg.ErrWrongPassword},
+			{"Unchanged", service.EmailChangeRequest{NewEmail: "Hasty@Example.com", Password: "password123"}, pkg.ErrEmailUnchanged},
+			{"Taken", service.EmailChangeRequest{NewEmail: "Taken@example.com", Password: "password123"}, pkg.ErrEmailExists},
+			{"First", service.EmailChangeRequest{NewEmail: "first@example.com", Password: "password123"}, nil},
+			{"Cooldown", service.EmailChangeRequest{NewEmail: "second@example.com", Password: "password123"}, pkg.ErrEmailChangeWait},
+		} {
+			if err := s.emailChange.Request(ctx, user.ID, tc.req); !errors.Is(err, tc.want) {
+				t.Fatalf("%s: Request = %v, want %v", tc.name, err, tc.want)
+			}
+		}
+	})
+}
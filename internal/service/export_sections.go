@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+)
+
+// ProfileExport exports the account as stored in users
+func ProfileExport(userRepo repository.UserRepository) ExportSection {
+	return ExportSection{
+		Name:        "profile",
+		Description: "account profile",
+		Collect: func(ctx context.Context, userID uint) (interface{}, error) {
+			return userRepo.FindByID(ctx, userID)
+		},
+	}
+}
+
+// SettingsExport exports the effective value of every setting
+func SettingsExport(settingsService SettingsService) ExportSection {
+	return ExportSection{
+		Name:        "settings",
+		Description: "settings and preferences, defaults included",
+		Collect: func(ctx context.Context, userID uint) (interface{}, error) {
+			return settingsService.All(ctx, userID)
+		},
+	}
+}
+
+// PrivacyExport exports the profile visibility and the users added as contacts or blocked
+func PrivacyExport(profileService ProfileService) ExportSection {
+	return ExportSection{
+		Name:        "privacy",
+		Description: "profile visibility per field, contacts and blocked users",
+		Collect: func(ctx context.Context, userID uint) (interface{}, error) {
+			visibility, err := profileService.Visibility(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			contacts, err := profileService.ListRelated(ctx, userID, models.RelationContact)
+			if err != nil {
+				return nil, err
+			}
+			blocks, err := profileService.ListRelated(ctx, userID, models.RelationBlock)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"visibility": visibility, "contacts": contacts, "blocks": blocks}, nil
+		},
+	}
+}
+
+// SessionsExport exports every session, which is also the login history
+func SessionsExport(sessionRepo repository.SessionRepository) ExportSection {
+	return ExportSection{
+		Name:        "sessions",
+		Description: "login history: every session with its IP address and user agent, revoked and expired ones included",
+		Collect: func(ctx context.Context, userID uint) (interface{}, error) {
+			return sessionRepo.ListByUser(ctx, userID)
+		},
+	}
+}
+
+// EmailChangesExport exports the email change requests
+func EmailChangesExport(changeRepo repository.EmailChangeRepository) ExportSection {
+	return ExportSection{
+		Name:        "email_changes",
+		Description: "email change requests",
+		Collect: func(ctx context.Context, userID uint) (interface{}, error) {
+			return changeRepo.ListByUser(ctx, userID)
+		},
+	}
+}
+
+// PhoneVerificationsExport exports the phone verification codes sent, without the codes
+func PhoneVerificationsExport(verificationRepo repository.PhoneVerificationRepository) ExportSection {
+	return ExportSection{
+		Name:        "phone_verifications",
+		Description: "phone verification codes sent by SMS, without the codes",
+		Collect: func(ctx context.Context, userID uint) (interface{}, error) {
+			return verificationRepo.ListByUser(ctx, userID)
+		},
+	}
+}
+
+// ProfileHistoryExport exports every version of the profile, oldest first
+func ProfileHistoryExport(historyService ProfileHistoryService) ExportSection {
+	return ExportSection{
+		Name:        "profile_history",
+		Description: "every earlier version of the profile, with when and by whom it was changed",
+		Collect: func(ctx context.Context, userID uint) (interface{}, error) {
+			var versions []models.ProfileVersion
+			var beforeID uint
+			for {
+				page, err := historyService.List(ctx, userID, beforeID, MaxHistoryLimit)
+				if err != nil {
+					return nil, err
+				}
+				versions = append(versions, page...)
+				if len(page) < MaxHistoryLimit {
+					break
+				}
+				beforeID = page[len(page)-1].ID
+			}
+			slices.Reverse(versions)
+			return versions, nil
+		},
+	}
+}
+
+// AuditExport exports the audit entries by or about the user. Client details of entries by other
+// users, such as operators, and the diffs of entries about other users are left out.
+func AuditExport(auditRepo repository.AuditRepository) ExportSection {
+	return ExportSection{
+		Name:        "audit_log",
+		Description: "audit log of actions by or on the account: logins, profile changes, role changes, deletions",
+		Collect: func(ctx context.Context, userID uint) (interface{}, error) {
+			entries, err := auditRepo.List(ctx, repository.AuditFilter{UserID: userID})
+			if err != nil {
+				return nil, err
+			}
+			for i, e := range entries {
+				if e.ActorID == nil || *e.ActorID != userID {
+					entries[i].IP, entries[i].UserAgent, entries[i].RequestID = "", "", ""
+				}
+				if e.TargetID == nil || *e.TargetID != userID {
+					entries[i].Changes = nil
+				}
+			}
+			slices.Reverse(entries)
+			return entries, nil
+		},
+	}
+}
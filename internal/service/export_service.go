@@ -0,0 +1,368 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vayura/config"
+	"github.com/vayura/internal/mail"
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/pkg"
+)
+
+// ExportFormat identifies the layout of export archives; bump it when files change incompatibly
+const ExportFormat = "vayura-export/1"
+
+// ExportSection contributes one JSON file to personal data exports
+type ExportSection struct {
+	Name        string // file name without the .json extension
+	Description string // what the file holds, listed in the manifest
+	Collect     func(ctx context.Context, userID uint) (interface{}, error)
+}
+
+// exportManifest is written to manifest.json and lists every other file of the archive
+type exportManifest struct {
+	Format      string              `json:"format"`
+	UserID      uint                `json:"user_id"`
+	ExportID    uint                `json:"export_id"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	Files       []exportManifestRow `json:"files"`
+}
+
+type exportManifestRow struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// exportService implements ExportService interface
+type exportService struct {
+	userRepo       repository.UserRepository
+	exportRepo     repository.DataExportRepository
+	txManager      repository.TxManager
+	storageService StorageService
+	mailer         mail.Mailer
+	dir            string
+	cfg            config.AccountConfig
+	sections       []ExportSection
+	wake           chan struct{}
+}
+
+// NewExportService creates a new data export service; archives are written to dir and hold one
+// JSON file per section plus the user's uploaded files
+func NewExportService(userRepo repository.UserRepository, exportRepo repository.DataExportRepository, txManager repository.TxManager, storageService StorageService, mailer mail.Mailer, dir string, cfg config.AccountConfig, sections ...ExportSection) ExportService {
+	return &exportService{
+		userRepo:       userRepo,
+		exportRepo:     exportRepo,
+		txManager:      txManager,
+		storageService: storageService,
+		mailer:         mailer,
+		dir:            dir,
+		cfg:            cfg,
+		sections:       sections,
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// Request queues an export unless one is still being prepared, and wakes the worker
+func (s *exportService) Request(ctx context.Context, userID uint) (*models.DataExport, error) {
+	var export *models.DataExport
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		latest, err := s.exportRepo.Latest(ctx, userID)
+		if err != nil && !errors.Is(err, repository.ErrDataExportNotFound) {
+			return err
+		}
+		if latest != nil && (latest.Status == models.ExportPending || latest.Status == models.ExportRunning) {
+			return pkg.ErrExportInProgress
+		}
+
+		export = &models.DataExport{UserID: userID, Status: models.ExportPending, CreatedAt: time.Now()}
+		return s.exportRepo.Create(ctx, export)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return export, nil
+}
+
+func (s *exportService) Latest(ctx context.Context, userID uint) (*models.DataExport, error) {
+	return s.exportRepo.Latest(ctx, userID)
+}
+
+func (s *exportService) Open(ctx context.Context, token string) (*models.DataExport, error) {
+	export, err := s.exportRepo.FindByTokenHash(ctx, hashToken(token))
+	if errors.Is(err, repository.ErrDataExportNotFound) {
+		return nil, pkg.ErrExportLink
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !export.IsDownloadable(time.Now()) {
+		return nil, pkg.ErrExportLink
+	}
+	return export, nil
+}
+
+func (s *exportService) ProcessPending(ctx context.Context) (int, error) {
+	exports, err := s.exportRepo.ListByStatus(ctx, models.ExportPending)
+	if err != nil {
+		return 0, err
+	}
+
+	done := 0
+	for i := range exports {
+		export := &exports[i]
+		claimed, err := s.exportRepo.Claim(ctx, export.ID)
+		if err != nil {
+			return done, err
+		}
+		if !claimed {
+			continue
+		}
+		export.Status = models.ExportRunning
+		if err := s.process(ctx, export); err != nil {
+			log.Printf("❌ Data export %d of user %d failed: %v", export.ID, export.UserID, err)
+			continue
+		}
+		done++
+	}
+	return done, nil
+}
+
+func (s *exportService) Cleanup(ctx context.Context) (int, error) {
+	exports, err := s.exportRepo.ListExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for i := range exports {
+		export := &exports[i]
+		if err := os.Remove(export.File); err != nil && !os.IsNotExist(err) {
+			return i, err
+		}
+		export.Status = models.ExportExpired
+		export.File = ""
+		if err := s.exportRepo.Update(ctx, export); err != nil {
+			return i, err
+		}
+	}
+	return len(exports), nil
+}
+
+func (s *exportService) Run(ctx context.Context) {
+	s.resume(ctx)
+
+	ticker := time.NewTicker(s.cfg.ExportInterval)
+	defer ticker.Stop()
+
+	for {
+		built, err := s.ProcessPending(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("❌ Data export processing failed: %v", err)
+		case built > 0:
+			log.Printf("📦 Built %d data exports", built)
+		}
+		removed, err := s.Cleanup(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("❌ Data export cleanup failed: %v", err)
+		case removed > 0:
+			log.Printf("🧹 Removed %d expired data exports", removed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// resume queues again the exports a previous run was building when it stopped
+func (s *exportService) resume(ctx context.Context) {
+	exports, err := s.exportRepo.ListByStatus(ctx, models.ExportRunning)
+	if err != nil {
+		log.Printf("⚠️  Failed to list interrupted data exports: %v", err)
+		return
+	}
+	for i := range exports {
+		exports[i].Status = models.ExportPending
+		if err := s.exportRepo.Update(ctx, &exports[i]); err != nil {
+			log.Printf("⚠️  Failed to resume data export %d: %v", exports[i].ID, err)
+		}
+	}
+}
+
+// process builds the archive of a claimed export and emails its link; on failure the export is
+// marked failed and the archive removed
+func (s *exportService) process(ctx context.Context, export *models.DataExport) error {
+	path := filepath.Join(s.dir, fmt.Sprintf("%d_%d.zip", export.UserID, export.ID))
+	err := s.complete(ctx, export, path)
+	if err == nil {
+		return nil
+	}
+
+	os.Remove(path)
+	now := time.Now()
+	export.Status = models.ExportFailed
+	export.TokenHash = nil
+	export.File = ""
+	export.Size = 0
+	export.CompletedAt = &now
+	export.ExpiresAt = nil
+	if updateErr := s.exportRepo.Update(ctx, export); updateErr != nil {
+		return fmt.Errorf("%w (marking it failed: %v)", err, updateErr)
+	}
+	return err
+}
+
+// complete builds the archive and marks the export ready once its link is mailed
+func (s *exportService) complete(ctx context.Context, export *models.DataExport, path string) error {
+	user, err := s.userRepo.FindByID(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+	size, err := s.build(ctx, export, path)
+	if err != nil {
+		return err
+	}
+
+	token := newToken()
+	hash := hashToken(token)
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.ExportTTL)
+	export.Status = models.ExportReady
+	export.TokenHash = &hash
+	export.File = path
+	export.Size = size
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.exportRepo.Update(ctx, export); err != nil {
+			return err
+		}
+		// Mail inside the transaction so an export nobody can download is not marked ready
+		if err := s.mailer.Send(ctx, mail.Message{
+			To:      user.Email,
+			Subject: "Your data export is ready",
+			Body: fmt.Sprintf("Hi %s,\n\nThe copy of your data you asked for is ready. Download it with this link:\n\n%s\n\nThe link expires at %s. If you did not ask for this, change your password.\n",
+				user.FullName, s.link(token), expiresAt.Format(time.RFC1123)),
+		}); err != nil {
+			return fmt.Errorf("failed to send export email: %w", err)
+		}
+		return nil
+	})
+}
+
+// build writes the archive to path and returns its size; the manifest is written last since it
+// lists the checksums of the other files
+func (s *exportService) build(ctx context.Context, export *models.DataExport, path string) (int64, error) {
+	// Archives are a full copy of the user's personal data, readable by the server only
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return 0, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	manifest := exportManifest{Format: ExportFormat, UserID: export.UserID, ExportID: export.ID, GeneratedAt: time.Now()}
+
+	for _, section := range s.sections {
+		data, err := section.Collect(ctx, export.UserID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to collect %s: %w", section.Name, err)
+		}
+		b, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return 0, err
+		}
+		row, err := writeExportFile(zw, section.Name+".json", b, manifest.GeneratedAt)
+		if err != nil {
+			return 0, err
+		}
+		row.Description = section.Description
+		manifest.Files = append(manifest.Files, row)
+	}
+
+	files, err := s.storageService.UserFiles(ctx, export.UserID)
+	if err != nil {
+		return 0, err
+	}
+	for _, name := range files {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return 0, err
+		}
+		row, err := writeExportFile(zw, "files/"+filepath.Base(name), b, manifest.GeneratedAt)
+		if err != nil {
+			return 0, err
+		}
+		row.Description = "uploaded file"
+		manifest.Files = append(manifest.Files, row)
+	}
+
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if _, err := writeExportFile(zw, "manifest.json", b, manifest.GeneratedAt); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (s *exportService) link(token string) string {
+	return strings.TrimRight(s.cfg.PublicURL, "/") + "/api/exports/download?token=" + url.QueryEscape(token)
+}
+
+// writeExportFile adds a file to the archive and returns its manifest row
+func writeExportFile(zw *zip.Writer, name string, data []byte, modified time.Time) (exportManifestRow, error) {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return exportManifestRow{}, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return exportManifestRow{}, err
+	}
+	sum := sha256.Sum256(data)
+	return exportManifestRow{Name: name, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])}, nil
+}
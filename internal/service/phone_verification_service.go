@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/vayura/config"
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/internal/sms"
+	"github.com/vayura/pkg"
+)
+
+// otpDigits is the length of phone verification codes
+const otpDigits = 6
+
+// PhoneVerifyRequest carries the code received by SMS
+type PhoneVerifyRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// phoneVerificationService implements PhoneVerificationService interface
+type phoneVerificationService struct {
+	userRepo         repository.UserRepository
+	verificationRepo repository.PhoneVerificationRepository
+	txManager        repository.TxManager
+	auditService     AuditService
+	sender           sms.SMSSender
+	cfg              config.AccountConfig
+}
+
+// NewPhoneVerificationService creates a new phone verification service
+func NewPhoneVerificationService(userRepo repository.UserRepository, verificationRepo repository.PhoneVerificationRepository, txManager repository.TxManager, auditService AuditService, sender sms.SMSSender, cfg config.AccountConfig) PhoneVerificationService {
+	return &phoneVerificationService{
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		txManager:        txManager,
+		auditService:     auditService,
+		sender:           sender,
+		cfg:              cfg,
+	}
+}
+
+// SendCode texts a new code to the profile phone number, at most once per cooldown;
+// the new code replaces any code sent before
+func (s *phoneVerificationService) SendCode(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Phone == "" {
+		return pkg.ErrPhoneRequired
+	}
+	if user.PhoneVerifiedAt != nil {
+		return pkg.ErrPhoneVerified
+	}
+
+	now := time.Now()
+	latest, err := s.verificationRepo.Latest(ctx, userID)
+	switch {
+	case errors.Is(err, repository.ErrPhoneVerificationNotFound):
+	case err != nil:
+		return err
+	case now.Sub(latest.CreatedAt) < s.cfg.PhoneOTPCooldown:
+		return pkg.ErrOTPCooldown
+	}
+
+	code := newOTP()
+	return s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		verification := &models.PhoneVerification{
+			UserID:    userID,
+			Phone:     user.Phone,
+			CodeHash:  hashOTP(user.Phone, code),
+			CreatedAt: now,
+			ExpiresAt: now.Add(s.cfg.PhoneOTPTTL),
+		}
+		if err := s.verificationRepo.Create(ctx, verification); err != nil {
+			return err
+		}
+
+		// Send inside the transaction so a code that never arrived does not start a cooldown
+		message := fmt.Sprintf("Your Vayura verification code is %s. It expires in %d minutes. Do not share it with anyone.",
+			code, max(1, int(s.cfg.PhoneOTPTTL/time.Minute)))
+		if err := s.sender.Send(ctx, user.Phone, message); err != nil {
+			return pkg.WrapError(pkg.ErrUnavailable, "SMS_UNAVAILABLE", "failed to send SMS", err)
+		}
+		return nil
+	})
+}
+
+// Verify checks code against the latest code sent to the current phone number and marks the number verified.
+// Every guess counts, and a code stops being accepted after the configured number of wrong guesses.
+func (s *phoneVerificationService) Verify(ctx context.Context, userID uint, code string) (*models.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.PhoneVerifiedAt != nil {
+		return nil, pkg.ErrPhoneVerified
+	}
+	if !isOTP(code) {
+		return nil, pkg.ErrOTPInvalid
+	}
+
+	verification, err := s.verificationRepo.Latest(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// Codes sent to a number the user has since replaced are void
+	if !verification.IsPending(time.Now()) || verification.Phone != user.Phone {
+		return nil, pkg.ErrOTPNotFound
+	}
+
+	allowed, err := s.verificationRepo.UseAttempt(ctx, verification.ID, s.cfg.PhoneOTPMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, pkg.ErrOTPAttempts
+	}
+	if subtle.ConstantTimeCompare([]byte(hashOTP(user.Phone, code)), []byte(verification.CodeHash)) != 1 {
+		return nil, pkg.ErrOTPInvalid
+	}
+
+	err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		verification.VerifiedAt = &now
+		if err := s.verificationRepo.Update(ctx, verification); err != nil {
+			return err
+		}
+		before := *user
+		user.PhoneVerifiedAt = &now
+		if err := s.userRepo.Update(ctx, user, "phone_verified_at"); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, models.AuditPhoneVerified, userID, models.DiffUsers(&before, user))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// newOTP returns a random numeric code of otpDigits digits
+func newOTP() string {
+	max := big.NewInt(1)
+	for i := 0; i < otpDigits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, _ := rand.Int(rand.Reader, max)
+	return fmt.Sprintf("%0*d", otpDigits, n)
+}
+
+// isOTP reports whether code has the shape of a code; others are rejected without using a guess
+func isOTP(code string) bool {
+	if len(code) != otpDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// hashOTP binds code to the number it was sent to
+func hashOTP(phoneNumber, code string) string {
+	return hashToken(phoneNumber + ":" + code)
+}
@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository/repotest"
+	"github.com/vayura/pkg"
+)
+
+// wrongCode returns a code of the same shape that differs from code
+func wrongCode(code string) string {
+	last := code[len(code)-1]
+	return code[:len(code)-1] + string('0'+(last-'0'+1)%10)
+}
+
+func TestPhoneVerification(t *testing.T) {
+	ctx := context.Background()
+
+	// sendCode registers a user with a phone number and texts it a code
+	sendCode := func(t *testing.T, s *testServices, username string) (*models.User, string) {
+		t.Helper()
+		req := registerRequest(username)
+		req.Phone = "+6281234567890"
+		user, err := s.auth.Register(ctx, req)
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		if err := s.phone.SendCode(ctx, user.ID); err != nil {
+			t.Fatalf("SendCode: %v", err)
+		}
+		return user, s.texts.code(t)
+	}
+
+	t.Run("Verify", func(t *testing.T) {
+		s := newTestServices(t, repotest.OpenSQLite(t))
+		user, code := sendCode(t, s, "caller")
+
+		if err := s.phone.SendCode(ctx, user.ID); !errors.Is(err, pkg.ErrOTPCooldown) {
+			t.Fatalf("second SendCode = %v, want ErrOTPCooldown", err)
+		}
+		if _, err := s.phone.Verify(ctx, user.ID, wrongCode(code)); !errors.Is(err, pkg.ErrOTPInvalid) {
+			t.Fatalf("Verify(wrong) = %v, want ErrOTPInvalid", err)
+		}
+		got, err := s.phone.Verify(ctx, user.ID, code)
+		if err != nil {
+			t.Fatalf("Verify: %v", err)
+		}
+		if got.PhoneVerifiedAt == nil {
+			t.Fatal("phone_verified_at not set")
+		}
+		if _, err := s.phone.Verify(ctx, user.ID, code); !errors.Is(err, pkg.ErrPhoneVerified) {
+			t.Fatalf("Verify again = %v, want ErrPhoneVerified", err)
+		}
+	})
+
+	t.Run("AttemptsExhausted", func(t *testing.T) {
+		s := newTestServices(t, repotest.OpenSQLite(t))
+		user, code := sendCode(t, s, "guesser")
+
+		for i := 0; i < testAccount.PhoneOTPMaxAttempts; i++ {
+			if _, err := s.phone.Verify(ctx, user.ID, wrongCode(code)); !errors.Is(err, pkg.ErrOTPInvalid) {
+				t.Fatalf("guess %d = %v, want ErrOTPInvalid", i+1, err)
+			}
+		}
+		// the right code no longer helps once the guesses are used up
+		if _, err := s.phone.Verify(ctx, user.ID, code); !errors.Is(err, pkg.ErrOTPAttempts) {
+			t.Fatalf("Verify after %d wrong guesses = %v, want ErrOTPAttempts", testAccount.PhoneOTPMaxAttempts, err)
+		}
+		// a malformed code does not use a guess and is rejected as invalid
+		if _, err := s.phone.Verify(ctx, user.ID, "12ab"); !errors.Is(err, pkg.ErrOTPInvalid) {
+			t.Fatalf("Verify(malformed) = %v, want ErrOTPInvalid", err)
+		}
+	})
+
+	t.Run("ExpiredCode", func(t *testing.T) {
+		s := newTestServices(t, repotest.OpenSQLite(t))
+		user, code := sendCode(t, s, "dawdler")
+		if err := s.db.Model(&models.PhoneVerification{}).Where("user_id = ?", user.ID).Update("expires_at", time.Now().Add(-time.Second)).Error; err != nil {
+			t.Fatalf("expire code: %v", err)
+		}
+
+		if _, err := s.phone.Verify(ctx, user.ID, code); !errors.Is(err, pkg.ErrOTPNotFound) {
+			t.Fatalf("Verify(expired) = %v, want ErrOTPNotFound", err)
+		}
+	})
+
+	t.Run("ReplacedNumber", func(t *testing.T) {
+		s := newTestServices(t, repotest.OpenSQLite(t))
+		user, code := sendCode(t, s, "switcher")
+		if _, err := s.user.PatchProfile(ctx, user.ID, 0, profilePatch(t, `{"phone": "+6281298765432"}`)); err != nil {
+			t.Fatalf("PatchProfile: %v", err)
+		}
+
+		if _, err := s.phone.Verify(ctx, user.ID, code); !errors.Is(err, pkg.ErrOTPNotFound) {
+			t.Fatalf("Verify(code of the old number) = %v, want ErrOTPNotFound", err)
+		}
+	})
+
+	t.Run("NoPhone", func(t *testing.T) {
+		s := newTestServices(t, repotest.OpenSQLite(t))
+		user, err := s.auth.Register(ctx, registerRequest("silent"))
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		if err := s.phone.SendCode(ctx, user.ID); !errors.Is(err, pkg.ErrPhoneRequired) {
+			t.Fatalf("SendCode = %v, want ErrPhoneRequired", err)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/identity"
+)
+
+// Profile history listing limits
+const (
+	DefaultHistoryLimit = 50
+	MaxHistoryLimit     = 200
+)
+
+// profileHistoryService implements ProfileHistoryService interface
+type profileHistoryService struct {
+	historyRepo   repository.ProfileHistoryRepository
+	reuseCooldown time.Duration
+}
+
+// NewProfileHistoryService creates a new profile history service; usernames given up by an account
+// cannot be taken by another one for reuseCooldown
+func NewProfileHistoryService(historyRepo repository.ProfileHistoryRepository, reuseCooldown time.Duration) ProfileHistoryService {
+	return &profileHistoryService{historyRepo: historyRepo, reuseCooldown: reuseCooldown}
+}
+
+func (s *profileHistoryService) Record(ctx context.Context, user *models.User) error {
+	version := models.ProfileVersionOf(user)
+	current, err := s.historyRepo.Current(ctx, user.ID)
+	switch {
+	case err == nil && current.SameProfile(version):
+		return nil
+	case err != nil && !errors.Is(err, repository.ErrProfileVersionNotFound):
+		return err
+	}
+
+	actor := pkg.ActorFromContext(ctx)
+	version.CreatedAt = time.Now()
+	version.Source = actor.Source
+	if actor.UserID != 0 {
+		version.ActorID = &actor.UserID
+	}
+	return s.historyRepo.Append(ctx, version)
+}
+
+func (s *profileHistoryService) List(ctx context.Context, userID, beforeID uint, limit int) ([]models.ProfileVersion, error) {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	if limit > MaxHistoryLimit {
+		limit = MaxHistoryLimit
+	}
+	return s.historyRepo.List(ctx, userID, beforeID, limit)
+}
+
+func (s *profileHistoryService) AsOf(ctx context.Context, userID uint, t time.Time) (*models.ProfileVersion, error) {
+	return s.historyRepo.AsOf(ctx, userID, t)
+}
+
+func (s *profileHistoryService) CheckUsername(ctx context.Context, userID uint, username string) error {
+	if s.reuseCooldown <= 0 {
+		return nil
+	}
+	released, err := s.historyRepo.LastReleased(ctx, identity.Skeleton(username), userID)
+	if err != nil {
+		return err
+	}
+	if !released.IsZero() && time.Since(released) < s.reuseCooldown {
+		return pkg.ErrUsernameCooldown
+	}
+	return nil
+}
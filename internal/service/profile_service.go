@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/pkg"
+)
+
+// PublicProfile is the projection of a user shown to other users; fields the viewer
+// may not see are left out
+type PublicProfile struct {
+	Username string `json:"username"`
+	FullName string `json:"full_name,omitempty"`
+	Avatar   string `json:"avatar,omitempty"`
+	Gender   string `json:"gender,omitempty"`
+	Birthday string `json:"birthday,omitempty"` // YYYY-MM-DD
+	Email    string `json:"email,omitempty"`
+	Phone    string `json:"phone,omitempty"`
+}
+
+// profileService implements ProfileService interface
+type profileService struct {
+	userRepo    repository.UserRepository
+	privacyRepo repository.PrivacyRepository
+}
+
+// NewProfileService creates a new profile service
+func NewProfileService(userRepo repository.UserRepository, privacyRepo repository.PrivacyRepository) ProfileService {
+	return &profileService{userRepo: userRepo, privacyRepo: privacyRepo}
+}
+
+// PublicProfile hides suspended users and users who blocked the viewer behind ErrUserNotFound,
+// so a blocked viewer cannot tell the account exists
+func (s *profileService) PublicProfile(ctx context.Context, viewerID uint, username string) (*PublicProfile, error) {
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsSuspended() && user.ID != viewerID {
+		return nil, pkg.ErrUserNotFound
+	}
+
+	relation := ""
+	if viewerID != 0 && viewerID != user.ID {
+		relation, err = s.privacyRepo.Relation(ctx, user.ID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if relation == models.RelationBlock {
+			return nil, pkg.ErrUserNotFound
+		}
+	}
+
+	visibility, err := s.Visibility(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	visible := func(field string) bool {
+		switch visibility[field] {
+		case models.VisibilityPublic:
+			return true
+		case models.VisibilityContacts:
+			return viewerID == user.ID || relation == models.RelationContact
+		}
+		return viewerID == user.ID
+	}
+
+	profile := &PublicProfile{Username: user.Username}
+	if visible("full_name") {
+		profile.FullName = user.FullName
+	}
+	if visible("avatar") {
+		profile.Avatar = user.Avatar
+	}
+	if visible("gender") {
+		profile.Gender = user.Gender
+	}
+	if visible("birthday") && !user.Birthday.IsZero() {
+		profile.Birthday = user.Birthday.Format("2006-01-02")
+	}
+	if visible("email") {
+		profile.Email = user.Email
+	}
+	if visible("phone") {
+		profile.Phone = user.Phone
+	}
+	return profile, nil
+}
+
+// Visibility returns the effective visibility of every field, defaults included
+func (s *profileService) Visibility(ctx context.Context, userID uint) (map[string]string, error) {
+	chosen, err := s.privacyRepo.Visibility(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	visibility := make(map[string]string, len(models.DefaultVisibility))
+	for field, v := range models.DefaultVisibility {
+		visibility[field] = v
+		if c, ok := chosen[field]; ok && models.IsValidVisibility(c) {
+			visibility[field] = c
+		}
+	}
+	return visibility, nil
+}
+
+func (s *profileService) UpdateVisibility(ctx context.Context, userID uint, changes map[string]string) (map[string]string, error) {
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var details []pkg.ErrorDetail
+	for _, field := range fields {
+		if _, ok := models.DefaultVisibility[field]; !ok {
+			details = append(details, pkg.ErrorDetail{Field: field, Rule: "unknown", Message: "is not a profile field that can be hidden"})
+		} else if !models.IsValidVisibility(changes[field]) {
+			details = append(details, pkg.ErrorDetail{Field: field, Rule: "oneof", Param: "public, contacts, private", Message: "must be public, contacts or private"})
+		}
+	}
+	if len(details) > 0 {
+		return nil, &pkg.Error{Kind: pkg.ErrValidation, Code: pkg.CodeValidationFailed, Message: "request validation failed", Details: details}
+	}
+
+	if err := s.privacyRepo.SetVisibility(ctx, userID, changes); err != nil {
+		return nil, err
+	}
+	return s.Visibility(ctx, userID)
+}
+
+// AddRelation makes username a contact of the user or blocks them, replacing the previous relation
+func (s *profileService) AddRelation(ctx context.Context, userID uint, username, kind string) error {
+	other, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if other.ID == userID {
+		return pkg.ErrSelfRelation
+	}
+	return s.privacyRepo.SetRelation(ctx, &models.Relation{UserID: userID, OtherID: other.ID, Kind: kind, CreatedAt: time.Now()})
+}
+
+// RemoveRelation removes username from the contacts or blocks of the user; removing a missing relation succeeds
+func (s *profileService) RemoveRelation(ctx context.Context, userID uint, username, kind string) error {
+	other, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	return s.privacyRepo.DeleteRelation(ctx, userID, other.ID, kind)
+}
+
+// ListRelated returns the usernames of the user's contacts or blocked users
+func (s *profileService) ListRelated(ctx context.Context, userID uint, kind string) ([]string, error) {
+	users, err := s.privacyRepo.ListRelated(ctx, userID, kind)
+	if err != nil {
+		return nil, err
+	}
+	usernames := make([]string, 0, len(users))
+	for _, u := range users {
+		usernames = append(usernames, u.Username)
+	}
+	return usernames, nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/vayura/config"
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+)
+
+// purgeService implements PurgeService interface
+type purgeService struct {
+	userRepo       repository.UserRepository
+	txManager      repository.TxManager
+	storageService StorageService
+	auditService   AuditService
+	anonymizers    []repository.UserAnonymizer
+	cfg            config.AccountConfig
+}
+
+// NewPurgeService creates a new purge service. Records referencing the user are removed by their
+// ON DELETE CASCADE foreign keys, except those of anonymizers, which are kept without personal data.
+func NewPurgeService(userRepo repository.UserRepository, txManager repository.TxManager, storageService StorageService, auditService AuditService, cfg config.AccountConfig, anonymizers ...repository.UserAnonymizer) PurgeService {
+	return &purgeService{
+		userRepo:       userRepo,
+		txManager:      txManager,
+		storageService: storageService,
+		auditService:   auditService,
+		anonymizers:    anonymizers,
+		cfg:            cfg,
+	}
+}
+
+func (s *purgeService) Purge(ctx context.Context, userID uint) error {
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		return s.purge(ctx, userID)
+	})
+	if err != nil {
+		return err
+	}
+	s.removeFiles(ctx, userID)
+	return nil
+}
+
+func (s *purgeService) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	users, err := s.userRepo.List(ctx, repository.ListOptions{OnlyDeleted: true, DeletedBefore: before})
+	if err != nil {
+		return 0, err
+	}
+
+	// Each user is purged on its own, so one failing account does not hold back the others
+	purged, failed := 0, 0
+	for _, u := range users {
+		if ctx.Err() != nil {
+			return purged, ctx.Err()
+		}
+		if err := s.Purge(ctx, u.ID); err != nil {
+			log.Printf("❌ Failed to purge user %d: %v", u.ID, err)
+			failed++
+			continue
+		}
+		purged++
+	}
+	if failed > 0 {
+		return purged, fmt.Errorf("failed to purge %d of %d deleted users", failed, len(users))
+	}
+	return purged, nil
+}
+
+func (s *purgeService) PurgeExpired(ctx context.Context) (int, error) {
+	return s.PurgeDeleted(ctx, time.Now().Add(-s.cfg.DeletionGrace))
+}
+
+func (s *purgeService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.PurgeExpired(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("❌ Account purge failed: %v", err)
+		}
+		if purged > 0 {
+			log.Printf("🗑️  Purged %d deleted accounts", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge anonymizes the records that outlive the user, deletes the row and records the purge;
+// callers hold a transaction
+func (s *purgeService) purge(ctx context.Context, userID uint) error {
+	for _, a := range s.anonymizers {
+		if err := a.AnonymizeUser(ctx, userID); err != nil {
+			return err
+		}
+	}
+	if err := s.userRepo.HardDelete(ctx, userID); err != nil {
+		return err
+	}
+	return s.auditService.Record(ctx, models.AuditPurged, userID, nil)
+}
+
+// removeFiles deletes the stored files of a purged user; leftovers are only logged since the account is gone
+func (s *purgeService) removeFiles(ctx context.Context, userID uint) {
+	if err := s.storageService.RemoveUserFiles(ctx, userID); err != nil {
+		log.Printf("⚠️  Failed to remove files of purged user %d: %v", userID, err)
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/vayura/config"
+	"github.com/vayura/internal/repository"
+)
+
+// reencryptService implements ReencryptService interface
+type reencryptService struct {
+	encryptionRepo repository.EncryptionRepository
+	cfg            config.PIIConfig
+}
+
+// NewReencryptService creates a new re-encryption service
+func NewReencryptService(encryptionRepo repository.EncryptionRepository, cfg config.PIIConfig) ReencryptService {
+	return &reencryptService{encryptionRepo: encryptionRepo, cfg: cfg}
+}
+
+func (s *reencryptService) Reencrypt(ctx context.Context) (int, error) {
+	total := 0
+	for _, table := range repository.EncryptedTables() {
+		var afterID uint
+		for {
+			last, rewritten, err := s.encryptionRepo.Reencrypt(ctx, table, afterID, s.cfg.ReencryptBatch)
+			total += rewritten
+			if err != nil {
+				return total, err
+			}
+			if last == 0 {
+				break
+			}
+			afterID = last
+		}
+	}
+	return total, nil
+}
+
+func (s *reencryptService) KeyUsage(ctx context.Context) (map[string]int64, error) {
+	return s.encryptionRepo.KeyUsage(ctx)
+}
+
+func (s *reencryptService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.ReencryptInterval)
+	defer ticker.Stop()
+
+	for {
+		rewritten, err := s.Reencrypt(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("❌ Re-encryption failed: %v", err)
+		case rewritten > 0:
+			log.Printf("🔐 Re-encrypted %d rows with the active key", rewritten)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vayura/config"
+	"github.com/vayura/internal/mail"
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg/identity"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	models.PasswordCost = bcrypt.MinCost
+	os.Exit(m.Run())
+}
+
+// testAccount is the account configuration of the services under test
+var testAccount = config.AccountConfig{
+	PublicURL:             "https://vayura.test",
+	EmailChangeTTL:        time.Hour,
+	EmailChangeCooldown:   time.Minute,
+	PhoneOTPTTL:           10 * time.Minute,
+	PhoneOTPCooldown:      time.Minute,
+	PhoneOTPMaxAttempts:   3,
+	DeletionGrace:         time.Hour,
+	UsernameReuseCooldown: 24 * time.Hour,
+}
+
+// testServices are the services under test, wired like app.New over a migrated test database
+type testServices struct {
+	db          *gorm.DB
+	auth        service.AuthService
+	user        service.UserService
+	session     service.SessionService
+	emailChange service.EmailChangeService
+	phone       service.PhoneVerificationService
+	policy      service.UsernamePolicy
+
+	mails *mailbox
+	texts *textbox
+}
+
+func newTestServices(t *testing.T, db *gorm.DB) *testServices {
+	t.Helper()
+	userRepo := repository.NewUserRepository(db)
+	txManager := repository.NewTxManager(db)
+	auditService := service.NewAuditService(repository.NewAuditRepository(db), config.AuditConfig{})
+	historyService := service.NewProfileHistoryService(repository.NewProfileHistoryRepository(db), testAccount.UsernameReuseCooldown)
+	rules := identity.NewUsernameRules(3, 30, nil, nil)
+	policy := service.NewUsernamePolicy(rules, userRepo, repository.NewUsernameGrantRepository(db), txManager, auditService, historyService)
+	sessionService := service.NewSessionService(repository.NewSessionRepository(db))
+	mails, texts := &mailbox{}, &textbox{}
+
+	return &testServices{
+		db:          db,
+		auth:        service.NewAuthService(userRepo, txManager, auditService, historyService, policy, testAccount.DeletionGrace),
+		user:        service.NewUserService(userRepo, txManager, sessionService, auditService, historyService, policy, testAccount.DeletionGrace),
+		session:     sessionService,
+		emailChange: service.NewEmailChangeService(userRepo, repository.NewEmailChangeRepository(db), txManager, sessionService, auditService, historyService, mails, testAccount),
+		phone:       service.NewPhoneVerificationService(userRepo, repository.NewPhoneVerificationRepository(db), txManager, auditService, texts, testAccount),
+		policy:      policy,
+		mails:       mails,
+		texts:       texts,
+	}
+}
+
+// startSession records an active session of the user and returns its ID
+func (s *testServices) startSession(t *testing.T, userID uint) string {
+	t.Helper()
+	session := &models.Session{ID: fmt.Sprintf("session-%d-%d", userID, time.Now().UnixNano()), UserID: userID, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := s.db.Create(session).Error; err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	return session.ID
+}
+
+// mailbox is a mail.Mailer keeping the messages it was asked to send
+type mailbox struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func (m *mailbox) Send(ctx context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func (m *mailbox) Check(ctx context.Context) error { return nil }
+
+// last returns the latest message sent to to
+func (m *mailbox) last(t *testing.T, to string) mail.Message {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i]
+		}
+	}
+	t.Fatalf("no message was sent to %s", to)
+	return mail.Message{}
+}
+
+// textbox is an sms.SMSSender keeping the messages it was asked to send
+type textbox struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (b *textbox) Send(ctx context.Context, to, message string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, message)
+	return nil
+}
+
+// code returns the digits of the latest message
+func (b *textbox) code(t *testing.T) string {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.messages) == 0 {
+		t.Fatal("no text message was sent")
+	}
+	code := regexp.MustCompile(`\d{4,}`).FindString(b.messages[len(b.messages)-1])
+	if code == "" {
+		t.Fatalf("no code in %q", b.messages[len(b.messages)-1])
+	}
+	return code
+}
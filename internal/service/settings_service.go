@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/internal/settings"
+	"github.com/vayura/pkg"
+)
+
+// settingsService implements SettingsService interface
+type settingsService struct {
+	settingsRepo repository.SettingsRepository
+	registry     *settings.Registry
+	cache        *settings.Cache
+}
+
+// NewSettingsService creates a new settings service for the settings in registry
+func NewSettingsService(settingsRepo repository.SettingsRepository, registry *settings.Registry, cache *settings.Cache) SettingsService {
+	return &settingsService{settingsRepo: settingsRepo, registry: registry, cache: cache}
+}
+
+func (s *settingsService) All(ctx context.Context, userID uint) (map[string]interface{}, error) {
+	values, err := s.values(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		all[key] = value
+	}
+	return all, nil
+}
+
+func (s *settingsService) Update(ctx context.Context, userID uint, patch map[string]json.RawMessage) (map[string]interface{}, error) {
+	keys := make([]string, 0, len(patch))
+	for key := range patch {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var (
+		details []pkg.ErrorDetail
+		set     = make(map[string]string, len(patch))
+		reset   []string
+	)
+	for _, key := range keys {
+		raw := patch[key]
+		if _, ok := s.registry.Lookup(key); ok && string(raw) == "null" {
+			reset = append(reset, key)
+			continue
+		}
+		value, err := s.registry.Decode(key, raw)
+		if err != nil {
+			details = append(details, pkg.ErrorDetails(err)...)
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		set[key] = string(encoded)
+	}
+	if len(details) > 0 {
+		return nil, &pkg.Error{Kind: pkg.ErrValidation, Code: pkg.CodeValidationFailed, Message: "request validation failed", Details: details}
+	}
+
+	if err := s.settingsRepo.Save(ctx, userID, set, reset); err != nil {
+		return nil, err
+	}
+	s.cache.Invalidate(userID)
+	return s.All(ctx, userID)
+}
+
+func (s *settingsService) String(ctx context.Context, userID uint, key string) (string, error) {
+	value, err := s.value(ctx, userID, key)
+	if err != nil {
+		return "", err
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("setting %q is not a string", key)
+	}
+	return str, nil
+}
+
+func (s *settingsService) Bool(ctx context.Context, userID uint, key string) (bool, error) {
+	value, err := s.value(ctx, userID, key)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("setting %q is not a boolean", key)
+	}
+	return b, nil
+}
+
+func (s *settingsService) value(ctx context.Context, userID uint, key string) (interface{}, error) {
+	if _, ok := s.registry.Lookup(key); !ok {
+		return nil, fmt.Errorf("setting %q is not registered", key)
+	}
+	values, err := s.values(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return values[key], nil
+}
+
+// values returns the effective settings of the user from the cache, loading them on a miss
+func (s *settingsService) values(ctx context.Context, userID uint) (map[string]interface{}, error) {
+	if values, ok := s.cache.Get(userID); ok {
+		return values, nil
+	}
+
+	overrides, err := s.settingsRepo.Overrides(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	values := s.registry.Defaults()
+	for key, raw := range overrides {
+		value, err := s.registry.Decode(key, json.RawMessage(raw))
+		if err != nil {
+			// Keys dropped from the registry or values it no longer accepts fall back to the default
+			log.Printf("⚠️  user %d: ignoring stored setting %s=%s: %v", userID, key, raw, err)
+			continue
+		}
+		values[key] = value
+	}
+	s.cache.Put(userID, values)
+	return values, nil
+}
@@ -10,10 +10,17 @@ import (
 	"time"
 
 	"github.com/vayura/config"
+	"github.com/vayura/pkg"
 )
 
 type StorageService interface {
 	SaveAvatar(ctx context.Context, userID uint, file *multipart.FileHeader) (string, error)
+	// RemoveAvatar deletes an avatar stored by SaveAvatar, given the path SaveAvatar returned
+	RemoveAvatar(ctx context.Context, avatarPath string) error
+	// UserFiles returns the paths of the files the user uploaded, including replaced avatars
+	UserFiles(ctx context.Context, userID uint) ([]string, error)
+	// RemoveUserFiles deletes every stored file of the user: uploads and data exports
+	RemoveUserFiles(ctx context.Context, userID uint) error
 }
 
 type storageService struct {
@@ -32,40 +39,80 @@ func (s *storageService) SaveAvatar(ctx context.Context, userID uint, file *mult
 
 	// Validasi ukuran file
 	if file.Size > 2*1024*1024 {
-		return "", fmt.Errorf("file too large (max 2MB)")
+		return "", &pkg.ValidationError{Field: "avatar", Rule: "max_size", Param: "2MB", Message: "file too large (max 2MB)"}
 	}
 
 	// Validasi ekstensi file
 	ext := strings.ToLower(filepath.Ext(file.Filename))
 	validExt := map[string]bool{".jpg": true, ".jpeg": true, ".png": true}
 	if !validExt[ext] {
-		return "", fmt.Errorf("invalid file type (only jpg, jpeg, png allowed)")
+		return "", &pkg.ValidationError{Field: "avatar", Rule: "file_type", Param: "jpg, jpeg, png", Message: "invalid file type (only jpg, jpeg, png allowed)"}
 	}
 
-	// Generate nama file unik
-	newFileName := fmt.Sprintf("%d_%d%s", userID, time.Now().Unix(), ext)
-	fullPath := filepath.Join(uploadPath, newFileName)
+	// Nama file unik per upload: <userID>_<waktu>_<acak>, jadi dua upload dalam detik yang sama
+	// tidak saling menimpa
+	out, err := os.CreateTemp(uploadPath, fmt.Sprintf("%d_%d_*%s", userID, time.Now().Unix(), ext))
+	if err != nil {
+		return "", err
+	}
 
-	// Simpan file
-	if err := saveUploadedFile(file, fullPath); err != nil {
+	// Simpan file; CreateTemp membuat file 0600, avatar dibaca publik
+	err = out.Chmod(0o644)
+	if err == nil {
+		err = saveUploadedFile(file, out)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out.Name())
 		return "", err
 	}
 
-	return "/" + fullPath, nil
+	return "/" + out.Name(), nil
 }
 
-func saveUploadedFile(file *multipart.FileHeader, dst string) error {
-	src, err := file.Open()
-	if err != nil {
+func (s *storageService) RemoveAvatar(ctx context.Context, avatarPath string) error {
+	name := filepath.Clean(strings.TrimPrefix(avatarPath, "/"))
+	if filepath.Dir(name) != filepath.Clean(s.cfg.Storage.UploadDir) {
+		return fmt.Errorf("avatar %s is not in the upload directory", avatarPath)
+	}
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
 		return err
 	}
-	defer src.Close()
+	return nil
+}
+
+func (s *storageService) UserFiles(ctx context.Context, userID uint) ([]string, error) {
+	return userFiles(s.cfg.Storage.UploadDir, userID)
+}
+
+func (s *storageService) RemoveUserFiles(ctx context.Context, userID uint) error {
+	for _, dir := range []string{s.cfg.Storage.UploadDir, s.cfg.Storage.ExportDir} {
+		matches, err := userFiles(dir, userID)
+		if err != nil {
+			return err
+		}
+		for _, name := range matches {
+			if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// userFiles returns the files of the user in dir; files are named <userId>_<suffix>
+func userFiles(dir string, userID uint) ([]string, error) {
+	return filepath.Glob(filepath.Join(dir, fmt.Sprintf("%d_*", userID)))
+}
 
-	out, err := os.Create(dst)
+func saveUploadedFile(file *multipart.FileHeader, out *os.File) error {
+	src, err := file.Open()
 	if err != nil {
 		return err
 	}
-	defer out.Close()
+	defer src.Close()
 
 	_, err = out.ReadFrom(src)
 	return err
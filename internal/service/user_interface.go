@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
 )
 
 // AuthService defines the interface for authentication operations
@@ -12,10 +15,159 @@ type AuthService interface {
 	Login(ctx context.Context, req LoginRequest) (*models.User, error)
 }
 
-// UserService defines the interface for user operations
+// UserService defines the interface for user operations.
+// Writes take the version the client based them on (its If-Match); 0 skips the check.
 type UserService interface {
 	GetProfile(ctx context.Context, userID uint) (*models.User, error)
-	UpdateProfile(ctx context.Context, userID uint, req UpdateProfileRequest) (*models.User, error)
-	DeleteProfile(ctx context.Context, userID uint) error
-	UpdateAvatar(ctx context.Context, userID uint, avatarPath string) (*models.User, error)
+	UpdateProfile(ctx context.Context, userID uint, version int64, req UpdateProfileRequest) (*models.User, error)
+	PatchProfile(ctx context.Context, userID uint, version int64, patch ProfilePatch) (*models.User, error)
+	DeleteProfile(ctx context.Context, userID uint, version int64) (time.Time, error)
+	UpdateAvatar(ctx context.Context, userID uint, version int64, avatarPath string) (*models.User, error)
+}
+
+// SessionService defines the interface for login sessions; every issued JWT belongs to one
+type SessionService interface {
+	// Start records a session for the user and returns its signed token
+	Start(ctx context.Context, user *models.User, ip, userAgent string) (string, error)
+	// Validate returns pkg.ErrInvalidToken unless the session is active and belongs to the user
+	Validate(ctx context.Context, userID uint, sessionID string) error
+	RevokeAll(ctx context.Context, userID uint) error
+}
+
+// EmailChangeService defines the interface for changing the account email; the change is
+// applied only when the new address confirms it and can be cancelled from the old address
+type EmailChangeService interface {
+	Request(ctx context.Context, userID uint, req EmailChangeRequest) error
+	Confirm(ctx context.Context, token string) error
+	Cancel(ctx context.Context, token string) error
+}
+
+// PhoneVerificationService defines the interface for proving ownership of the profile phone number by SMS code
+type PhoneVerificationService interface {
+	SendCode(ctx context.Context, userID uint) error
+	Verify(ctx context.Context, userID uint, code string) (*models.User, error)
+}
+
+// ProfileService defines the interface for public profiles, their per-field visibility and the
+// contact and block relations that visibility depends on
+type ProfileService interface {
+	// PublicProfile returns the profile of username as seen by viewerID (0 for anonymous visitors)
+	PublicProfile(ctx context.Context, viewerID uint, username string) (*PublicProfile, error)
+	Visibility(ctx context.Context, userID uint) (map[string]string, error)
+	UpdateVisibility(ctx context.Context, userID uint, changes map[string]string) (map[string]string, error)
+	AddRelation(ctx context.Context, userID uint, username, kind string) error
+	RemoveRelation(ctx context.Context, userID uint, username, kind string) error
+	ListRelated(ctx context.Context, userID uint, kind string) ([]string, error)
+}
+
+// SettingsService defines the interface for per-user settings. String and Bool are cached and
+// meant for other services; they fail only for keys that are not registered with that type.
+type SettingsService interface {
+	// All returns the effective value of every registered setting
+	All(ctx context.Context, userID uint) (map[string]interface{}, error)
+	// Update applies a merge patch of settings; null resets a setting to its default
+	Update(ctx context.Context, userID uint, patch map[string]json.RawMessage) (map[string]interface{}, error)
+	String(ctx context.Context, userID uint, key string) (string, error)
+	Bool(ctx context.Context, userID uint, key string) (bool, error)
+}
+
+// PurgeService defines the interface for permanently removing accounts: the user row, related
+// records (cascaded or anonymized) and stored files
+type PurgeService interface {
+	Purge(ctx context.Context, userID uint) error
+	// PurgeDeleted purges the users soft-deleted before the given time, each in its own transaction;
+	// users that fail are logged and skipped, and an error reports how many failed
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+	// PurgeExpired purges the deleted accounts whose grace period is over
+	PurgeExpired(ctx context.Context) (int, error)
+	// Run purges expired accounts periodically until ctx is done
+	Run(ctx context.Context)
+}
+
+// ExportService defines the interface for personal data exports. A background worker builds the
+// requested archives and emails a download link that expires after a while.
+type ExportService interface {
+	// Request queues an export of everything stored about the user
+	Request(ctx context.Context, userID uint) (*models.DataExport, error)
+	// Latest returns the most recent export of the user
+	Latest(ctx context.Context, userID uint) (*models.DataExport, error)
+	// Open returns the export behind an emailed download token while it can be downloaded
+	Open(ctx context.Context, token string) (*models.DataExport, error)
+	// ProcessPending builds the queued exports and returns how many are ready
+	ProcessPending(ctx context.Context) (int, error)
+	// Cleanup removes the archives of expired exports and returns how many were removed
+	Cleanup(ctx context.Context) (int, error)
+	// Run builds queued exports as they come in and removes expired ones until ctx is done
+	Run(ctx context.Context)
+}
+
+// ReencryptService defines the interface for moving encrypted values to the active key after a
+// key rotation
+type ReencryptService interface {
+	// Reencrypt rewrites every value wrapped by a retired key and returns how many rows changed
+	Reencrypt(ctx context.Context) (int, error)
+	// KeyUsage counts the encrypted values by key ID
+	KeyUsage(ctx context.Context) (map[string]int64, error)
+	// Run re-encrypts periodically until ctx is done
+	Run(ctx context.Context)
+}
+
+// AuditService defines the interface for the audit log of security-relevant and administrative actions
+type AuditService interface {
+	// Record appends an entry for an action of the actor of ctx on the target user (0 for none);
+	// call it inside the transaction of the change so both are written or neither
+	Record(ctx context.Context, action string, targetID uint, changes models.AuditChanges) error
+	// List returns the entries matching filter, newest first
+	List(ctx context.Context, filter repository.AuditFilter) ([]models.AuditEntry, error)
+	// Archive moves the entries older than the retention period to archive files and returns how many moved
+	Archive(ctx context.Context) (int, error)
+	// Verify walks the hash chain from the latest signed checkpoint, or from the oldest entry
+	// when full is set, and reports the first break
+	Verify(ctx context.Context, full bool) (*AuditVerification, error)
+	// Checkpoint verifies the entries since the latest checkpoint and signs a new one at the last
+	// entry; it returns nil when there are no new entries
+	Checkpoint(ctx context.Context) (*models.AuditCheckpoint, error)
+	// Run archives entries and adds checkpoints periodically until ctx is done
+	Run(ctx context.Context)
+}
+
+// ProfileHistoryService defines the interface for the versions of user profiles
+type ProfileHistoryService interface {
+	// Record adds a version when the profile of user differs from the current version; call it
+	// inside the transaction that saved user
+	Record(ctx context.Context, user *models.User) error
+	// List returns versions of the user older than beforeID (0 for the newest), newest first
+	List(ctx context.Context, userID, beforeID uint, limit int) ([]models.ProfileVersion, error)
+	// AsOf returns the profile of the user as it was at t
+	AsOf(ctx context.Context, userID uint, t time.Time) (*models.ProfileVersion, error)
+	// CheckUsername fails with ErrUsernameCooldown when another account than userID (0 for a new
+	// one) gave up username, or a look-alike, within the reuse cooldown
+	CheckUsername(ctx context.Context, userID uint, username string) error
+}
+
+// UsernamePolicy defines the rules of usernames shared by registration and profile updates
+type UsernamePolicy interface {
+	// Check runs the rules for userID (0 for a new account) changing its username from current
+	// ("" for none) to username: format and profanity, then, unless only letter case or look-alike
+	// characters change, availability, the reuse cooldown and reserved words, in that order, so a
+	// caller allowed to take reserved usernames can ignore identity.ErrUsernameReserved
+	Check(ctx context.Context, userID uint, current, username string) error
+	// Grant allows the user to take a reserved username and its look-alikes
+	Grant(ctx context.Context, username string, userID uint) (*models.UsernameGrant, error)
+	// Revoke removes the grant of a reserved username; a user holding the username keeps it
+	Revoke(ctx context.Context, username string) error
+	// ListGrants returns every grant ordered by username
+	ListGrants(ctx context.Context) ([]models.UsernameGrant, error)
+}
+
+// AdminService defines operator tasks shared by the CLI and admin tooling
+type AdminService interface {
+	FindUser(ctx context.Context, ref string) (*models.User, error)
+	CreateUser(ctx context.Context, req RegisterRequest) (*models.User, error)
+	ListUsers(ctx context.Context, opts repository.ListOptions) ([]models.User, error)
+	SetRole(ctx context.Context, userID uint, role string) (*models.User, error)
+	ResetPassword(ctx context.Context, userID uint, password string) error
+	Suspend(ctx context.Context, userID uint, suspended bool) (*models.User, error)
+	DeleteUser(ctx context.Context, userID uint, hard bool) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
 }
@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"slices"
+	"sort"
 	"time"
 
 	"github.com/vayura/internal/models"
@@ -11,90 +14,257 @@ import (
 
 // userService implements UserService interface
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo       repository.UserRepository
+	txManager      repository.TxManager
+	sessionService SessionService
+	auditService   AuditService
+	historyService ProfileHistoryService
+	usernamePolicy UsernamePolicy
+	deletionGrace  time.Duration
 }
 
-// NewUserService creates a new user service
-func NewUserService(userRepo repository.UserRepository) UserService {
-	return &userService{userRepo: userRepo}
+// NewUserService creates a new user service; deleted profiles are purged after deletionGrace
+func NewUserService(userRepo repository.UserRepository, txManager repository.TxManager, sessionService SessionService, auditService AuditService, historyService ProfileHistoryService, usernamePolicy UsernamePolicy, deletionGrace time.Duration) UserService {
+	return &userService{userRepo: userRepo, txManager: txManager, sessionService: sessionService, auditService: auditService, historyService: historyService, usernamePolicy: usernamePolicy, deletionGrace: deletionGrace}
 }
 
-// UpdateProfileRequest represents the update profile request
+// UpdateProfileRequest represents the update profile request; it replaces the whole profile,
+// so omitted optional fields are cleared
 type UpdateProfileRequest struct {
-	FullName string `json:"full_name"`
-	Username string `json:"username"`
+	FullName string `json:"full_name" binding:"required,min=3"`
+	Username string `json:"username" binding:"required"`
 	Phone    string `json:"phone"`
 	Gender   string `json:"gender"`
-	Birthday string `json:"birthday"`
+	Birthday string `json:"birthday"` // format YYYY-MM-DD
 }
 
+// ProfilePatch is a JSON Merge Patch (RFC 7396) of the profile: absent fields are unchanged
+// and fields set to null are cleared
+type ProfilePatch map[string]json.RawMessage
+
+// profileColumns are the columns a profile update may write, in the order patches are validated
+var profileColumns = []string{"full_name", "username", "phone", "gender", "birthday"}
+
 func (s *userService) GetProfile(ctx context.Context, userID uint) (*models.User, error) {
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
-		return nil, pkg.ErrUserNotFound
+		return nil, err
 	}
 	return user, nil
 }
 
-func (s *userService) UpdateProfile(ctx context.Context, userID uint, req UpdateProfileRequest) (*models.User, error) {
-	user, err := s.userRepo.FindByID(ctx, userID)
+func (s *userService) UpdateProfile(ctx context.Context, userID uint, version int64, req UpdateProfileRequest) (*models.User, error) {
+	if err := validateFullName(req.FullName); err != nil {
+		return nil, err
+	}
+	birth, err := parseBirthday(req.Birthday)
 	if err != nil {
-		return nil, pkg.ErrUserNotFound
+		return nil, err
+	}
+	phoneNumber, err := normalizePhone(req.Phone)
+	if err != nil {
+		return nil, err
 	}
 
-	// Update fields if provided
-	if req.FullName != "" {
+	return s.updateProfile(ctx, userID, version, func(user *models.User) []string {
 		user.FullName = req.FullName
-	}
-	if req.Username != "" {
-		// Check if username already exists
-		if req.Username != user.Username {
-			usernameExists, err := s.userRepo.UsernameExists(ctx, req.Username)
-			if err != nil {
-				return nil, err
-			}
-			if usernameExists {
-				return nil, pkg.ErrUsernameExists
-			}
-		}
 		user.Username = req.Username
+		user.Phone = phoneNumber
+		user.Gender = req.Gender
+		user.Birthday = birth
+		return profileColumns
+	})
+}
+
+// PatchProfile applies a JSON Merge Patch to the profile, validating every field with the registration rules;
+// a new username is checked against the username policy once the other fields are valid
+func (s *userService) PatchProfile(ctx context.Context, userID uint, version int64, patch ProfilePatch) (*models.User, error) {
+	var (
+		details []pkg.ErrorDetail
+		changes = make(map[string]interface{}, len(patch))
+	)
+	for _, column := range profileColumns {
+		raw, ok := patch[column]
+		if !ok {
+			continue
+		}
+		value, err := patchValue(column, raw)
+		if err != nil {
+			details = append(details, pkg.ErrorDetails(err)...)
+			continue
+		}
+		changes[column] = value
 	}
-	if req.Phone != "" {
-		user.Phone = req.Phone
+	for _, field := range unknownFields(patch) {
+		details = append(details, pkg.ErrorDetail{Field: field, Rule: "unknown", Message: "is not a profile field that can be changed"})
 	}
-	if req.Gender != "" {
-		user.Gender = req.Gender
+	if len(details) > 0 {
+		return nil, &pkg.Error{Kind: pkg.ErrValidation, Code: pkg.CodeValidationFailed, Message: "request validation failed", Details: details}
 	}
-	if req.Birthday != "" {
-		birth, err := time.Parse("2006-01-02", req.Birthday)
+
+	return s.updateProfile(ctx, userID, version, func(user *models.User) []string {
+		var columns []string
+		for _, column := range profileColumns {
+			value, ok := changes[column]
+			if !ok {
+				continue
+			}
+			switch column {
+			case "full_name":
+				user.FullName = value.(string)
+			case "username":
+				user.Username = value.(string)
+			case "phone":
+				user.Phone = value.(string)
+			case "gender":
+				user.Gender = value.(string)
+			case "birthday":
+				user.Birthday = value.(time.Time)
+			}
+			columns = append(columns, column)
+		}
+		return columns
+	})
+}
+
+// updateProfile loads the user at version, applies change and saves the columns it returns,
+// checking first that a new username passes the username policy; a new phone number has to be
+// verified again
+func (s *userService) updateProfile(ctx context.Context, userID uint, version int64, change func(user *models.User) []string) (*models.User, error) {
+	var user *models.User
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.findVersion(ctx, userID, version)
 		if err != nil {
-			return nil, &pkg.ValidationError{Field: "birthday", Message: "invalid birthday format, use YYYY-MM-DD"}
+			return err
+		}
+
+		before := *user
+		previous, previousPhone := user.Username, user.Phone
+		columns := change(user)
+		if len(columns) == 0 {
+			return nil
+		}
+		if user.Phone != previousPhone && user.PhoneVerifiedAt != nil {
+			user.PhoneVerifiedAt = nil
+			columns = append(columns[:len(columns):len(columns)], "phone_verified_at")
+		}
+
+		if err := s.usernamePolicy.Check(ctx, userID, previous, user.Username); err != nil {
+			return err
 		}
-		user.Birthday = birth
-	}
 
-	// Save updated user
-	if err := s.userRepo.Update(ctx, user); err != nil {
+		// Save only the updated columns
+		if err := s.userRepo.Update(ctx, user, columns...); err != nil {
+			return err
+		}
+		if err := s.historyService.Record(ctx, user); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, models.AuditProfileUpdated, userID, models.DiffUsers(&before, user))
+	})
+	if err != nil {
 		return nil, err
 	}
-
 	return user, nil
 }
 
-func (s *userService) DeleteProfile(ctx context.Context, userID uint) error {
-	return s.userRepo.Delete(ctx, userID)
+// DeleteProfile schedules the account for purge and signs out every session; it returns the purge time
+func (s *userService) DeleteProfile(ctx context.Context, userID uint, version int64) (time.Time, error) {
+	deletedAt := time.Now()
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.findVersion(ctx, userID, version); err != nil {
+			return err
+		}
+		if err := s.userRepo.Delete(ctx, userID); err != nil {
+			return err
+		}
+		if err := s.auditService.Record(ctx, models.AuditDeleted, userID, nil); err != nil {
+			return err
+		}
+		return s.sessionService.RevokeAll(ctx, userID)
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return deletedAt.Add(s.deletionGrace), nil
 }
 
-func (s *userService) UpdateAvatar(ctx context.Context, userID uint, avatarPath string) (*models.User, error) {
-	user, err := s.userRepo.FindByID(ctx, userID)
+func (s *userService) UpdateAvatar(ctx context.Context, userID uint, version int64, avatarPath string) (*models.User, error) {
+	var user *models.User
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = s.findVersion(ctx, userID, version)
+		if err != nil {
+			return err
+		}
+		before := *user
+		user.Avatar = avatarPath
+		if err := s.userRepo.Update(ctx, user, "avatar"); err != nil {
+			return err
+		}
+		if err := s.historyService.Record(ctx, user); err != nil {
+			return err
+		}
+		return s.auditService.Record(ctx, models.AuditAvatarUpdated, userID, models.DiffUsers(&before, user))
+	})
 	if err != nil {
-		return nil, pkg.ErrUserNotFound
+		return nil, err
 	}
+	return user, nil
+}
 
-	user.Avatar = avatarPath
-	if err := s.userRepo.Update(ctx, user); err != nil {
+// findVersion loads the user and checks it is still at version, unless version is 0
+func (s *userService) findVersion(ctx context.Context, userID uint, version int64) (*models.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
 		return nil, err
 	}
-
+	if version != 0 && user.Version != version {
+		return nil, pkg.ErrVersionMismatch
+	}
 	return user, nil
 }
+
+// patchValue decodes and validates the merge patch value of a profile column; null clears
+// optional fields and is rejected for required ones
+func patchValue(column string, raw json.RawMessage) (interface{}, error) {
+	if string(raw) == "null" {
+		switch column {
+		case "full_name", "username":
+			return nil, &pkg.ValidationError{Field: column, Rule: "required", Message: column + " cannot be cleared"}
+		case "birthday":
+			return time.Time{}, nil
+		}
+		return "", nil
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, &pkg.ValidationError{Field: column, Rule: "type", Param: "string", Message: column + " must be a string"}
+	}
+	switch column {
+	case "full_name":
+		return value, validateFullName(value)
+	case "phone":
+		return normalizePhone(value)
+	case "birthday":
+		if value == "" {
+			return nil, &pkg.ValidationError{Field: column, Rule: "date", Message: "invalid birthday format, use YYYY-MM-DD"}
+		}
+		return parseBirthday(value)
+	}
+	return value, nil
+}
+
+// unknownFields returns the sorted patch fields that are not profile columns
+func unknownFields(patch ProfilePatch) []string {
+	var fields []string
+	for field := range patch {
+		if !slices.Contains(profileColumns, field) {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,111 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository/repotest"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+)
+
+// profilePatch builds a merge patch from a JSON object
+func profilePatch(t *testing.T, body string) service.ProfilePatch {
+	t.Helper()
+	var patch service.ProfilePatch
+	if err := json.Unmarshal([]byte(body), &patch); err != nil {
+		t.Fatalf("decode patch %s: %v", body, err)
+	}
+	return patch
+}
+
+func TestPatchProfile(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServices(t, repotest.OpenSQLite(t))
+
+	req := registerRequest("patchy")
+	req.Phone, req.Gender, req.Birthday = "+6281234567890", "female", "1990-01-02"
+	user, err := s.auth.Register(ctx, req)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	verifiedAt := time.Now()
+	if err := s.db.Model(&models.User{}).Where("id = ?", user.ID).Update("phone_verified_at", verifiedAt).Error; err != nil {
+		t.Fatalf("mark phone verified: %v", err)
+	}
+
+	t.Run("NullClears", func(t *testing.T) {
+		if _, err := s.user.PatchProfile(ctx, user.ID, 0, profilePatch(t, `{"gender": null, "birthday": null}`)); err != nil {
+			t.Fatalf("PatchProfile: %v", err)
+		}
+		got, err := s.user.GetProfile(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("GetProfile: %v", err)
+		}
+		if got.Gender != "" || !got.Birthday.IsZero() {
+			t.Fatalf("gender %q, birthday %v; want both cleared", got.Gender, got.Birthday)
+		}
+		if got.FullName != req.FullName || got.Phone != req.Phone || got.PhoneVerifiedAt == nil {
+			t.Fatalf("absent fields changed: %+v", got)
+		}
+	})
+
+	t.Run("PhoneChangeResetsVerification", func(t *testing.T) {
+		if _, err := s.user.PatchProfile(ctx, user.ID, 0, profilePatch(t, `{"phone": "+62 812-9876-5432"}`)); err != nil {
+			t.Fatalf("PatchProfile: %v", err)
+		}
+		got, err := s.user.GetProfile(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("GetProfile: %v", err)
+		}
+		if got.Phone != "+6281298765432" {
+			t.Fatalf("phone = %q, want the normalized new number", got.Phone)
+		}
+		if got.PhoneVerifiedAt != nil {
+			t.Fatalf("phone_verified_at = %v, want it reset with the new number", got.PhoneVerifiedAt)
+		}
+	})
+
+	t.Run("InvalidFields", func(t *testing.T) {
+		before, err := s.user.GetProfile(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("GetProfile: %v", err)
+		}
+		_, err = s.user.PatchProfile(ctx, user.ID, 0, profilePatch(t, `{"full_name": null, "birthday": "02/01/1990", "gender": "male", "email": "x@example.com"}`))
+		if !errors.Is(err, pkg.ErrValidation) {
+			t.Fatalf("PatchProfile = %v, want a validation error", err)
+		}
+		rules := map[string]string{}
+		for _, d := range pkg.ErrorDetails(err) {
+			rules[d.Field] = d.Rule
+		}
+		want := map[string]string{"full_name": "required", "birthday": "date", "email": "unknown"}
+		for field, rule := range want {
+			if rules[field] != rule {
+				t.Fatalf("details = %v, want %s rejected with %s", rules, field, rule)
+			}
+		}
+		if len(rules) != len(want) {
+			t.Fatalf("details = %v, want only %v", rules, want)
+		}
+
+		after, err := s.user.GetProfile(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("GetProfile: %v", err)
+		}
+		if after.Version != before.Version || after.Gender != before.Gender {
+			t.Fatal("a rejected patch changed the profile")
+		}
+	})
+
+	t.Run("StaleVersion", func(t *testing.T) {
+		_, err := s.user.PatchProfile(ctx, user.ID, 1, profilePatch(t, `{"gender": "male"}`))
+		if !errors.Is(err, pkg.ErrVersionMismatch) {
+			t.Fatalf("PatchProfile = %v, want ErrVersionMismatch", err)
+		}
+	})
+}
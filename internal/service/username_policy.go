@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vayura/internal/models"
+	"github.com/vayura/internal/repository"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/identity"
+)
+
+// usernamePolicy implements UsernamePolicy interface
+type usernamePolicy struct {
+	rules          *identity.UsernameRules
+	userRepo       repository.UserRepository
+	grantRepo      repository.UsernameGrantRepository
+	txManager      repository.TxManager
+	auditService   AuditService
+	historyService ProfileHistoryService
+}
+
+// NewUsernamePolicy creates a new username policy enforcing rules, uniqueness, the reuse cooldown
+// of historyService and the grants of reserved usernames
+func NewUsernamePolicy(rules *identity.UsernameRules, userRepo repository.UserRepository, grantRepo repository.UsernameGrantRepository, txManager repository.TxManager, auditService AuditService, historyService ProfileHistoryService) UsernamePolicy {
+	return &usernamePolicy{
+		rules:          rules,
+		userRepo:       userRepo,
+		grantRepo:      grantRepo,
+		txManager:      txManager,
+		auditService:   auditService,
+		historyService: historyService,
+	}
+}
+
+func (p *usernamePolicy) Check(ctx context.Context, userID uint, current, username string) error {
+	// Keeping the current username is always allowed; a new user (current "") has none to keep
+	if current != "" && identity.NormalizeUsername(username) == identity.NormalizeUsername(current) {
+		return nil
+	}
+	if err := p.rules.Validate(username); err != nil {
+		return err
+	}
+	// A change of letter case or look-alike characters keeps the identity
+	if current != "" && identity.Skeleton(username) == identity.Skeleton(current) {
+		return nil
+	}
+
+	usernameExists, err := p.userRepo.UsernameExists(ctx, username)
+	if err != nil {
+		return err
+	}
+	if usernameExists {
+		return pkg.ErrUsernameExists
+	}
+	if err := p.historyService.CheckUsername(ctx, userID, username); err != nil {
+		return err
+	}
+
+	if !p.rules.Reserved(username) {
+		return nil
+	}
+	grant, err := p.grantRepo.Find(ctx, identity.Skeleton(username))
+	switch {
+	case err == nil && userID != 0 && grant.UserID == userID:
+		return nil
+	case err != nil && !errors.Is(err, repository.ErrUsernameGrantNotFound):
+		return err
+	}
+	return identity.ErrUsernameReserved
+}
+
+func (p *usernamePolicy) Grant(ctx context.Context, username string, userID uint) (*models.UsernameGrant, error) {
+	if err := p.rules.Validate(username); err != nil {
+		return nil, err
+	}
+	if !p.rules.Reserved(username) {
+		return nil, &pkg.ValidationError{Field: "username", Rule: "not_reserved", Message: "username is not reserved"}
+	}
+
+	username = identity.NormalizeUsername(username)
+	grant := &models.UsernameGrant{
+		CreatedAt:        time.Now(),
+		Username:         username,
+		UsernameSkeleton: identity.Skeleton(username),
+		UserID:           userID,
+	}
+	if actor := pkg.ActorFromContext(ctx); actor.UserID != 0 {
+		grant.GrantedBy = &actor.UserID
+	}
+
+	// A grant to another user moves to this one
+	err := p.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := p.userRepo.FindByID(ctx, userID); err != nil {
+			return err
+		}
+		previous, err := p.grantRepo.Find(ctx, grant.UsernameSkeleton)
+		switch {
+		case err == nil && previous.UserID != userID:
+			if err := p.recordGrant(ctx, models.AuditUsernameRevoked, previous.UserID, previous.Username, nil); err != nil {
+				return err
+			}
+		case err != nil && !errors.Is(err, repository.ErrUsernameGrantNotFound):
+			return err
+		}
+		if err := p.grantRepo.Save(ctx, grant); err != nil {
+			return err
+		}
+		return p.recordGrant(ctx, models.AuditUsernameGranted, userID, nil, username)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+func (p *usernamePolicy) Revoke(ctx context.Context, username string) error {
+	skeleton := identity.Skeleton(username)
+	return p.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		grant, err := p.grantRepo.Find(ctx, skeleton)
+		if err != nil {
+			return err
+		}
+		if err := p.grantRepo.Delete(ctx, skeleton); err != nil {
+			return err
+		}
+		return p.recordGrant(ctx, models.AuditUsernameRevoked, grant.UserID, grant.Username, nil)
+	})
+}
+
+func (p *usernamePolicy) ListGrants(ctx context.Context) ([]models.UsernameGrant, error) {
+	return p.grantRepo.List(ctx)
+}
+
+// recordGrant records a change of the username grant of the user in the audit log
+func (p *usernamePolicy) recordGrant(ctx context.Context, action string, userID uint, from, to interface{}) error {
+	return p.auditService.Record(ctx, action, userID, models.AuditChanges{"username_grant": {Old: from, New: to}})
+}
@@ -0,0 +1,97 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vayura/internal/repository/repotest"
+	"github.com/vayura/internal/service"
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/identity"
+)
+
+func TestUsernamePolicy(t *testing.T) {
+	ctx := context.Background()
+
+	// rule returns the rule a username validation error was rejected with
+	rule := func(t *testing.T, err error) string {
+		t.Helper()
+		var verr *pkg.ValidationError
+		if !errors.As(err, &verr) || verr.Field != "username" {
+			t.Fatalf("err = %v, want a username validation error", err)
+		}
+		return verr.Rule
+	}
+
+	t.Run("BlankRegistration", func(t *testing.T) {
+		s := newTestServices(t, repotest.OpenSQLite(t))
+		for i, username := range []string{"", " ", "\t 　"} {
+			req := registerRequest("blank")
+			req.Username = username
+			req.Email = fmt.Sprintf("blank%d@example.com", i)
+			_, err := s.auth.Register(ctx, req)
+			if got := rule(t, err); got != "min" {
+				t.Fatalf("Register(%q) rejected with %s, want min", username, got)
+			}
+		}
+	})
+
+	t.Run("ReservedLookAlikes", func(t *testing.T) {
+		s := newTestServices(t, repotest.OpenSQLite(t))
+		for _, username := range []string{"admin", "4dm1n", "He1p", "supp0rt", "s.y.s.t.e.m"} {
+			req := registerRequest("someone")
+			req.Username = username
+			if _, err := s.auth.Register(ctx, req); !errors.Is(err, identity.ErrUsernameReserved) {
+				t.Fatalf("Register(%q) = %v, want ErrUsernameReserved", username, err)
+			}
+		}
+
+		user, err := s.auth.Register(ctx, registerRequest("renamer"))
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		_, err = s.user.UpdateProfile(ctx, user.ID, 0, service.UpdateProfileRequest{FullName: "Test Renamer", Username: "r00t"})
+		if !errors.Is(err, identity.ErrUsernameReserved) {
+			t.Fatalf("UpdateProfile(r00t) = %v, want ErrUsernameReserved", err)
+		}
+	})
+
+	t.Run("GrantedName", func(t *testing.T) {
+		s := newTestServices(t, repotest.OpenSQLite(t))
+		owner, err := s.auth.Register(ctx, registerRequest("helpdesk"))
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		other, err := s.auth.Register(ctx, registerRequest("bystander"))
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		if _, err := s.policy.Grant(ctx, "support", owner.ID); err != nil {
+			t.Fatalf("Grant: %v", err)
+		}
+		if _, err := s.policy.Grant(ctx, "helpdesk", owner.ID); rule(t, err) != "not_reserved" {
+			t.Fatalf("Grant(helpdesk) = %v, want not_reserved", err)
+		}
+
+		if _, err := s.user.UpdateProfile(ctx, other.ID, 0, service.UpdateProfileRequest{FullName: "Test Bystander", Username: "support"}); !errors.Is(err, identity.ErrUsernameReserved) {
+			t.Fatalf("UpdateProfile by another user = %v, want ErrUsernameReserved", err)
+		}
+		// the grant covers look-alikes of the granted name
+		got, err := s.user.UpdateProfile(ctx, owner.ID, 0, service.UpdateProfileRequest{FullName: "Test Helpdesk", Username: "Supp0rt"})
+		if err != nil {
+			t.Fatalf("UpdateProfile by the grantee: %v", err)
+		}
+		if got.Username != "Supp0rt" {
+			t.Fatalf("username = %q, want Supp0rt", got.Username)
+		}
+		// keeping the granted name is allowed after the grant is revoked
+		if err := s.policy.Revoke(ctx, "support"); err != nil {
+			t.Fatalf("Revoke: %v", err)
+		}
+		if _, err := s.user.UpdateProfile(ctx, owner.ID, 0, service.UpdateProfileRequest{FullName: "Test Helpdesk Renamed", Username: "Supp0rt"}); err != nil {
+			t.Fatalf("UpdateProfile keeping the name: %v", err)
+		}
+	})
+}
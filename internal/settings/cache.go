@@ -0,0 +1,70 @@
+package settings
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache keeps the effective settings of recently seen users for ttl. Entries are replaced when a
+// user changes settings through this process; other processes see the change after ttl at most.
+type Cache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[uint]cacheEntry
+}
+
+type cacheEntry struct {
+	values  map[string]interface{}
+	expires time.Time
+}
+
+// NewCache creates a cache holding at most maxEntries users; a ttl of 0 disables caching
+func NewCache(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{ttl: ttl, maxEntries: maxEntries, entries: make(map[uint]cacheEntry)}
+}
+
+// Get returns the cached settings of the user; the map must not be modified
+func (c *Cache) Get(userID uint) (map[string]interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[userID]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.values, true
+}
+
+// Put caches the settings of the user
+func (c *Cache) Put(userID uint, values map[string]interface{}) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.maxEntries {
+		// Drop expired entries first, then an arbitrary one if still full
+		for id, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, id)
+			}
+		}
+		for id := range c.entries {
+			if len(c.entries) < c.maxEntries {
+				break
+			}
+			delete(c.entries, id)
+		}
+	}
+	c.entries[userID] = cacheEntry{values: values, expires: now.Add(c.ttl)}
+}
+
+// Invalidate drops the cached settings of the user
+func (c *Cache) Invalidate(userID uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, userID)
+}
@@ -0,0 +1,171 @@
+// Package settings describes the per-user settings vayura knows about: their keys, types,
+// defaults and validation rules.
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata" // validate time zones on hosts without a zoneinfo database
+
+	"github.com/vayura/pkg"
+	"github.com/vayura/pkg/i18n"
+)
+
+// Well-known setting keys
+const (
+	KeyLanguage            = "language"
+	KeyTimezone            = "timezone"
+	KeyTheme               = "theme"
+	KeyNotifySecurityEmail = "notifications.security_email"
+	KeyNotifyProductEmail  = "notifications.product_email"
+	KeyNotifySMS           = "notifications.sms"
+	KeyMarketingConsent    = "marketing_consent"
+)
+
+// Definition describes one setting. The type of Default (string or bool) is the type of the setting.
+type Definition struct {
+	Key         string
+	Default     interface{}
+	Description string
+	// Validate checks a decoded value of the right type; nil accepts any value
+	Validate func(value interface{}) error
+}
+
+// Registry holds the known settings by key
+type Registry struct {
+	defs map[string]Definition
+}
+
+// NewRegistry creates a registry of defs; it panics on duplicate keys or unsupported default types
+func NewRegistry(defs ...Definition) *Registry {
+	r := &Registry{defs: make(map[string]Definition, len(defs))}
+	for _, def := range defs {
+		if _, dup := r.defs[def.Key]; dup {
+			panic(fmt.Sprintf("settings: duplicate key %q", def.Key))
+		}
+		switch def.Default.(type) {
+		case string, bool:
+		default:
+			panic(fmt.Sprintf("settings: unsupported type %T of %q", def.Default, def.Key))
+		}
+		r.defs[def.Key] = def
+	}
+	return r
+}
+
+// Lookup returns the definition of key
+func (r *Registry) Lookup(key string) (Definition, bool) {
+	def, ok := r.defs[key]
+	return def, ok
+}
+
+// Keys returns the registered keys, sorted
+func (r *Registry) Keys() []string {
+	keys := make([]string, 0, len(r.defs))
+	for key := range r.defs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Defaults returns the default value of every setting
+func (r *Registry) Defaults() map[string]interface{} {
+	values := make(map[string]interface{}, len(r.defs))
+	for key, def := range r.defs {
+		values[key] = def.Default
+	}
+	return values
+}
+
+// Decode parses and validates the JSON value of key; errors are *pkg.ValidationError for the key
+func (r *Registry) Decode(key string, raw json.RawMessage) (interface{}, error) {
+	def, ok := r.defs[key]
+	if !ok {
+		return nil, &pkg.ValidationError{Field: key, Rule: "unknown", Message: "is not a known setting"}
+	}
+
+	var value interface{}
+	switch def.Default.(type) {
+	case string:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, &pkg.ValidationError{Field: key, Rule: "type", Param: "string", Message: key + " must be a string"}
+		}
+		value = s
+	case bool:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return nil, &pkg.ValidationError{Field: key, Rule: "type", Param: "boolean", Message: key + " must be a boolean"}
+		}
+		value = b
+	}
+	if def.Validate != nil {
+		if err := def.Validate(value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// Builtin returns the registry of the settings vayura ships with
+func Builtin() *Registry {
+	return NewRegistry(
+		Definition{
+			Key:         KeyLanguage,
+			Default:     "",
+			Description: "language of messages; empty follows the Accept-Language header",
+			Validate:    validateLanguage,
+		},
+		Definition{
+			Key:         KeyTimezone,
+			Default:     "UTC",
+			Description: "IANA time zone used to show dates",
+			Validate:    validateTimezone,
+		},
+		Definition{
+			Key:         KeyTheme,
+			Default:     "system",
+			Description: "client color theme",
+			Validate:    oneOf(KeyTheme, "system", "light", "dark"),
+		},
+		Definition{Key: KeyNotifySecurityEmail, Default: true, Description: "email about sign-ins and account changes"},
+		Definition{Key: KeyNotifyProductEmail, Default: true, Description: "email about product updates"},
+		Definition{Key: KeyNotifySMS, Default: false, Description: "text messages other than verification codes"},
+		Definition{Key: KeyMarketingConsent, Default: false, Description: "consent to marketing messages"},
+	)
+}
+
+func validateLanguage(value interface{}) error {
+	locale := value.(string)
+	if locale != "" && !i18n.IsSupported(locale) {
+		supported := strings.Join(i18n.Supported(), ", ")
+		return &pkg.ValidationError{Field: KeyLanguage, Rule: "oneof", Param: supported, Message: "language must be one of: " + supported}
+	}
+	return nil
+}
+
+func validateTimezone(value interface{}) error {
+	name := value.(string)
+	// LoadLocation also accepts "" and "Local", which are not zones a client can use
+	if _, err := time.LoadLocation(name); err != nil || name == "" || name == "Local" {
+		return &pkg.ValidationError{Field: KeyTimezone, Rule: "timezone", Message: "timezone must be an IANA time zone such as Asia/Jakarta"}
+	}
+	return nil
+}
+
+// oneOf returns a validator accepting only the given strings
+func oneOf(key string, allowed ...string) func(value interface{}) error {
+	return func(value interface{}) error {
+		for _, a := range allowed {
+			if value.(string) == a {
+				return nil
+			}
+		}
+		param := strings.Join(allowed, ", ")
+		return &pkg.ValidationError{Field: key, Rule: "oneof", Param: param, Message: key + " must be one of: " + param}
+	}
+}
@@ -0,0 +1,42 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// consoleSender logs messages instead of delivering them, for development
+type consoleSender struct {
+	file string
+	mu   sync.Mutex
+}
+
+// NewConsoleSender creates a sender that logs every message and, when file is set, also appends it there
+func NewConsoleSender(file string) SMSSender {
+	return &consoleSender{file: file}
+}
+
+func (s *consoleSender) Send(ctx context.Context, to, message string) error {
+	log.Printf("📱 sms to %s: %s", to, message)
+	if s.file == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.file), 0o755); err != nil {
+		return fmt.Errorf("failed to create sms directory: %w", err)
+	}
+	f, err := os.OpenFile(s.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), to, message)
+	return err
+}
@@ -0,0 +1,63 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/vayura/config"
+)
+
+// httpSender posts messages to a generic SMS gateway as JSON
+type httpSender struct {
+	cfg    config.SMSConfig
+	client *http.Client
+}
+
+// NewHTTPSender creates a sender for the gateway in cfg. Each message is sent as
+// POST {"to": "+628...", "from": cfg.Sender, "message": "..."}; any 2xx response means accepted.
+func NewHTTPSender(cfg config.SMSConfig) SMSSender {
+	return &httpSender{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+// gatewayRequest is the body posted to the gateway
+type gatewayRequest struct {
+	To      string `json:"to"`
+	From    string `json:"from,omitempty"`
+	Message string `json:"message"`
+}
+
+func (s *httpSender) Send(ctx context.Context, to, message string) error {
+	body, err := json.Marshal(gatewayRequest{To: to, From: s.cfg.Sender, Message: message})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.GatewayURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.GatewayToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.cfg.GatewayToken)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms gateway unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms gateway returned %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	return nil
+}
+
+// Close drops the idle connections to the gateway
+func (s *httpSender) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,85 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vayura/config"
+)
+
+// gateway starts a test gateway answering with status and body, and returns the sender for it
+// and the last request it received
+func gateway(t *testing.T, token string, status int, body string) (SMSSender, *http.Request, *gatewayRequest) {
+	t.Helper()
+	var (
+		received = &http.Request{}
+		payload  = &gatewayRequest{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*received = *r.Clone(context.Background())
+		if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+			t.Errorf("decode gateway request: %v", err)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	sender := NewHTTPSender(config.SMSConfig{
+		Driver:       "http",
+		Sender:       "Vayura",
+		GatewayURL:   srv.URL,
+		GatewayToken: token,
+		Timeout:      5 * time.Second,
+	})
+	return sender, received, payload
+}
+
+func TestHTTPSenderSend(t *testing.T) {
+	sender, received, payload := gateway(t, "secret-token", http.StatusAccepted, "")
+
+	if err := sender.Send(context.Background(), "+628123456789", "Your code is 123456"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if received.Method != http.MethodPost {
+		t.Fatalf("method = %s, want POST", received.Method)
+	}
+	if got := received.Header.Get("Authorization"); got != "Bearer secret-token" {
+		t.Fatalf("Authorization = %q, want Bearer secret-token", got)
+	}
+	if got := received.Header.Get("Content-Type"); got != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", got)
+	}
+	want := gatewayRequest{To: "+628123456789", From: "Vayura", Message: "Your code is 123456"}
+	if *payload != want {
+		t.Fatalf("body = %+v, want %+v", *payload, want)
+	}
+}
+
+func TestHTTPSenderSendWithoutToken(t *testing.T) {
+	sender, received, _ := gateway(t, "", http.StatusOK, "")
+
+	if err := sender.Send(context.Background(), "+628123456789", "hello"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got := received.Header.Get("Authorization"); got != "" {
+		t.Fatalf("Authorization = %q, want none", got)
+	}
+}
+
+func TestHTTPSenderSendRejected(t *testing.T) {
+	sender, _, _ := gateway(t, "secret-token", http.StatusBadRequest, "invalid number\n")
+
+	err := sender.Send(context.Background(), "+628123456789", "hello")
+	if err == nil {
+		t.Fatal("Send succeeded on a 400 response")
+	}
+	if !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "invalid number") {
+		t.Fatalf("Send = %q, want the status and body of the response", err)
+	}
+}
@@ -0,0 +1,9 @@
+// Package sms sends text messages through a pluggable SMSSender.
+package sms
+
+import "context"
+
+// SMSSender delivers a text message to an E.164 phone number
+type SMSSender interface {
+	Send(ctx context.Context, to, message string) error
+}
@@ -0,0 +1,22 @@
+package sms
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vayura/config"
+)
+
+// New creates the sender selected by cfg.Driver
+func New(cfg config.SMSConfig) (SMSSender, error) {
+	switch cfg.Driver {
+	case config.SMSDriverConsole, "":
+		return NewConsoleSender(cfg.File), nil
+	case config.SMSDriverHTTP:
+		if cfg.GatewayURL == "" {
+			return nil, errors.New("SMS_GATEWAY_URL is required for the http sms driver")
+		}
+		return NewHTTPSender(cfg), nil
+	}
+	return nil, fmt.Errorf("unsupported sms driver %q", cfg.Driver)
+}
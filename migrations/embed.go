@@ -0,0 +1,9 @@
+// Package migrations embeds the versioned SQL migrations, one directory per database dialect.
+package migrations
+
+import "embed"
+
+// FS holds the migration files as <dialect>/<version>_<name>.<up|down>.sql
+//
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
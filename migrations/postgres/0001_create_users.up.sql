@@ -0,0 +1,20 @@
+-- Baseline schema, matches the tables previously created by GORM AutoMigrate.
+CREATE TABLE IF NOT EXISTS users (
+    id          BIGSERIAL PRIMARY KEY,
+    full_name   TEXT NOT NULL,
+    username    TEXT NOT NULL,
+    email       TEXT NOT NULL,
+    phone       TEXT,
+    avatar      TEXT,
+    gender      TEXT,
+    birthday    TIMESTAMPTZ,
+    role        TEXT DEFAULT 'user',
+    password    TEXT NOT NULL,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ,
+    deleted_at  TIMESTAMPTZ,
+    CONSTRAINT uni_users_username UNIQUE (username),
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
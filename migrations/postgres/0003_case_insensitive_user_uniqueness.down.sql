@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uni_users_username_lower;
+DROP INDEX IF EXISTS uni_users_email_lower;
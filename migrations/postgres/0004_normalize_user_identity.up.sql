@@ -0,0 +1,3 @@
+-- Emails and usernames are rewritten to their normalized form and username_skeleton is
+-- filled by the Go step of this migration, which fails with a report of colliding users.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username_skeleton TEXT NOT NULL DEFAULT '';
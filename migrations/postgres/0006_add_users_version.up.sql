@@ -0,0 +1,2 @@
+-- Row version for optimistic concurrency; every update increments it.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,18 @@
+-- Per-field profile visibility; fields without a row use the default of the application.
+CREATE TABLE IF NOT EXISTS profile_visibility (
+    user_id     BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    field       TEXT NOT NULL,
+    visibility  TEXT NOT NULL,
+    PRIMARY KEY (user_id, field)
+);
+
+-- One-way contact and block relations between users.
+CREATE TABLE IF NOT EXISTS user_relations (
+    user_id     BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    other_id    BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind        TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, other_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_relations_other_id ON user_relations (other_id);
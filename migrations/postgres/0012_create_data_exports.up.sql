@@ -0,0 +1,16 @@
+-- Personal data export jobs; the ZIP lives in EXPORT_DIR and only the download token hash is stored.
+CREATE TABLE IF NOT EXISTS data_exports (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status          TEXT NOT NULL,
+    token_hash      TEXT,
+    file            TEXT NOT NULL DEFAULT '',
+    size            BIGINT NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ NOT NULL,
+    completed_at    TIMESTAMPTZ,
+    expires_at      TIMESTAMPTZ,
+    CONSTRAINT uni_data_exports_token_hash UNIQUE (token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports (user_id);
+CREATE INDEX IF NOT EXISTS idx_data_exports_status ON data_exports (status);
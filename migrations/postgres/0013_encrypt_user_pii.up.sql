@@ -0,0 +1,6 @@
+-- Phone and birthday are encrypted by the application (pkg/fieldcrypt), so birthday becomes TEXT
+-- and phone gets a blind index for lookups. The Go step encrypts the existing values.
+ALTER TABLE users RENAME COLUMN birthday TO birthday_plain;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS birthday TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_index TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_users_phone_index ON users (phone_index);
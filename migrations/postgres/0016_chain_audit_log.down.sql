@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS audit_checkpoints;
+DROP TABLE IF EXISTS audit_chain;
+ALTER TABLE audit_entries DROP COLUMN hash;
+ALTER TABLE audit_entries DROP COLUMN client_hash;
+ALTER TABLE audit_entries DROP COLUMN changes_hash;
+ALTER TABLE audit_entries DROP COLUMN prev_hash;
@@ -0,0 +1,27 @@
+-- Hash chain over the audit log. Each entry hashes its content and the previous entry's hash;
+-- changes_hash and client_hash cover the parts that purging a user clears. The Go step chains
+-- existing entries.
+ALTER TABLE audit_entries ADD COLUMN prev_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_entries ADD COLUMN changes_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_entries ADD COLUMN client_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_entries ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+
+-- Single row holding the end of the chain; appends lock it, which serializes them
+CREATE TABLE IF NOT EXISTS audit_chain (
+    id              INTEGER PRIMARY KEY CHECK (id = 1),
+    last_id         BIGINT NOT NULL DEFAULT 0,
+    last_hash       TEXT NOT NULL DEFAULT ''
+);
+INSERT INTO audit_chain (id) VALUES (1);
+
+-- Signed checkpoints of the chain; they anchor incremental verification and outlive archived entries
+CREATE TABLE IF NOT EXISTS audit_checkpoints (
+    id              BIGSERIAL PRIMARY KEY,
+    created_at      TIMESTAMPTZ NOT NULL,
+    entry_id        BIGINT NOT NULL,
+    hash            TEXT NOT NULL,
+    key_id          TEXT NOT NULL DEFAULT '',
+    signature       TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_checkpoints_entry_id ON audit_checkpoints (entry_id);
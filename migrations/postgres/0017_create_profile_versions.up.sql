@@ -0,0 +1,22 @@
+-- Profile history: one row per version of a user's profile fields, valid from created_at until
+-- valid_to (NULL for the current one). No foreign key: the skeleton and times of purged users stay
+-- for the username reuse cooldown. The Go step adds the current version of every user.
+CREATE TABLE IF NOT EXISTS profile_versions (
+    id                  BIGSERIAL PRIMARY KEY,
+    user_id             BIGINT NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL,
+    valid_to            TIMESTAMPTZ,
+    actor_id            BIGINT,
+    source              TEXT NOT NULL,
+    full_name           TEXT NOT NULL DEFAULT '',
+    username            TEXT NOT NULL DEFAULT '',
+    username_skeleton   TEXT NOT NULL,
+    email               TEXT NOT NULL DEFAULT '',
+    phone               TEXT NOT NULL DEFAULT '',
+    avatar              TEXT NOT NULL DEFAULT '',
+    gender              TEXT NOT NULL DEFAULT '',
+    birthday            TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_versions_user_id_created_at ON profile_versions (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_profile_versions_username_skeleton ON profile_versions (username_skeleton, valid_to);
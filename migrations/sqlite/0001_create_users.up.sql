@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS users (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    full_name   TEXT NOT NULL,
+    username    TEXT NOT NULL,
+    email       TEXT NOT NULL,
+    phone       TEXT,
+    avatar      TEXT,
+    gender      TEXT,
+    birthday    DATETIME,
+    role        TEXT DEFAULT 'user',
+    password    TEXT NOT NULL,
+    created_at  DATETIME,
+    updated_at  DATETIME,
+    deleted_at  DATETIME,
+    CONSTRAINT uni_users_username UNIQUE (username),
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
@@ -0,0 +1,3 @@
+-- Email and username are unique regardless of letter case. The LOWER(...) unique indexes are
+-- created by the Go step of this migration, which first fails with a report of the users whose
+-- email or username differ only by case.
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS uni_users_username_skeleton;
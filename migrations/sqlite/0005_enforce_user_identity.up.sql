@@ -0,0 +1,2 @@
+-- Look-alike usernames (same skeleton) cannot coexist.
+CREATE UNIQUE INDEX IF NOT EXISTS uni_users_username_skeleton ON users (username_skeleton);
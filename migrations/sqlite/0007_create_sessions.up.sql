@@ -0,0 +1,12 @@
+-- Login sessions; the session ID is the jti claim of the JWT issued for it.
+CREATE TABLE IF NOT EXISTS sessions (
+    id          TEXT PRIMARY KEY,
+    user_id     INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    ip          TEXT,
+    user_agent  TEXT,
+    created_at  DATETIME NOT NULL,
+    expires_at  DATETIME NOT NULL,
+    revoked_at  DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
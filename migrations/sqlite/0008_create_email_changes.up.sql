@@ -0,0 +1,17 @@
+-- Pending and completed email change requests; only token hashes are stored.
+CREATE TABLE IF NOT EXISTS email_changes (
+    id                  INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id             INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    old_email           TEXT NOT NULL,
+    new_email           TEXT NOT NULL,
+    confirm_token_hash  TEXT NOT NULL,
+    cancel_token_hash   TEXT NOT NULL,
+    created_at          DATETIME NOT NULL,
+    expires_at          DATETIME NOT NULL,
+    confirmed_at        DATETIME,
+    cancelled_at        DATETIME,
+    CONSTRAINT uni_email_changes_confirm_token_hash UNIQUE (confirm_token_hash),
+    CONSTRAINT uni_email_changes_cancel_token_hash UNIQUE (cancel_token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_changes_user_id ON email_changes (user_id);
@@ -0,0 +1,15 @@
+-- Phone ownership: when the current number was verified, and the codes sent to verify it.
+ALTER TABLE users ADD COLUMN phone_verified_at DATETIME;
+
+CREATE TABLE IF NOT EXISTS phone_verifications (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id     INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    phone       TEXT NOT NULL,
+    code_hash   TEXT NOT NULL,
+    attempts    INTEGER NOT NULL DEFAULT 0,
+    created_at  DATETIME NOT NULL,
+    expires_at  DATETIME NOT NULL,
+    verified_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_phone_verifications_user_id ON phone_verifications (user_id);
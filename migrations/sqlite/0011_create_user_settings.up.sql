@@ -0,0 +1,8 @@
+-- Per-user overrides of registered settings, JSON encoded; keys without a row use the default.
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id     INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    key         TEXT NOT NULL,
+    value       TEXT NOT NULL,
+    updated_at  DATETIME NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
@@ -0,0 +1,5 @@
+-- The Go down step has decrypted phone and copied birthday back to birthday_plain.
+DROP INDEX IF EXISTS idx_users_phone_index;
+ALTER TABLE users DROP COLUMN phone_index;
+ALTER TABLE users DROP COLUMN birthday;
+ALTER TABLE users RENAME COLUMN birthday_plain TO birthday;
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN birthday_plain DATETIME;
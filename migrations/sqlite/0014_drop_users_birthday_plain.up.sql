@@ -0,0 +1,2 @@
+-- The plaintext birthdays were encrypted into birthday by 0013.
+ALTER TABLE users DROP COLUMN birthday_plain;
@@ -0,0 +1,18 @@
+-- Append-only audit log. Actor and target are not foreign keys: entries outlive purged users.
+CREATE TABLE IF NOT EXISTS audit_entries (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at      DATETIME NOT NULL,
+    actor_id        INTEGER,
+    source          TEXT NOT NULL,
+    target_id       INTEGER,
+    action          TEXT NOT NULL,
+    changes         TEXT NOT NULL DEFAULT '',
+    ip              TEXT NOT NULL DEFAULT '',
+    user_agent      TEXT NOT NULL DEFAULT '',
+    request_id      TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_entries_actor_id ON audit_entries (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_target_id ON audit_entries (target_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_action ON audit_entries (action);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_created_at ON audit_entries (created_at);
@@ -0,0 +1,12 @@
+-- Admin grants of reserved usernames; at most one user holds a grant per username skeleton.
+CREATE TABLE IF NOT EXISTS username_grants (
+    id                  INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at          DATETIME NOT NULL,
+    username            TEXT NOT NULL,
+    username_skeleton   TEXT NOT NULL,
+    user_id             INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    granted_by          INTEGER
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_username_grants_username_skeleton ON username_grants (username_skeleton);
+CREATE INDEX IF NOT EXISTS idx_username_grants_user_id ON username_grants (user_id);
@@ -0,0 +1 @@
+-- The Go down step has decrypted the phone numbers; the schema is unchanged.
@@ -0,0 +1,2 @@
+-- The phone number a code was sent to is encrypted by the application (pkg/fieldcrypt) like
+-- users.phone. The column is already TEXT; the Go step encrypts the existing values.
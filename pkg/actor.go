@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Where an action comes from
+const (
+	SourceAPI    = "api"
+	SourceCLI    = "cli"
+	SourceSystem = "system" // background workers
+)
+
+// Actor describes who performs an action, for the audit log
+type Actor struct {
+	UserID    uint // 0 when nobody is authenticated
+	Source    string
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type actorKey struct{}
+
+// WithActor returns a context carrying actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// WithActorUser returns a context whose actor is the given user, e.g. after a login
+func WithActorUser(ctx context.Context, userID uint) context.Context {
+	actor := ActorFromContext(ctx)
+	actor.UserID = userID
+	return WithActor(ctx, actor)
+}
+
+// ActorFromContext returns the actor of ctx; contexts without one belong to the system
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Source: SourceSystem}
+}
+
+// ActorMiddleware stores the client of the request as the actor of its context; it runs after
+// RequestID, and AuthMiddleware adds the authenticated user
+func ActorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(WithActor(c.Request.Context(), Actor{
+			Source:    SourceAPI,
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: GetRequestID(c),
+		}))
+		c.Next()
+	}
+}
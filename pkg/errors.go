@@ -1,24 +1,193 @@
 package pkg
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+)
 
-// Custom error types for better error handling
+// Error kinds; every application error wraps exactly one of them so it can be
+// matched with errors.Is and mapped to an HTTP status by ErrorHandler
 var (
-	ErrEmailExists        = errors.New("email already registered")
-	ErrUsernameExists     = errors.New("username already taken")
-	ErrInvalidCredentials = errors.New("invalid email or password")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrInvalidToken       = errors.New("invalid or expired token")
-	ErrMissingAuth        = errors.New("missing authorization header")
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
 	ErrValidation         = errors.New("validation error")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrUnavailable        = errors.New("service unavailable")
+)
+
+// Custom error types for better error handling
+var (
+	ErrEmailExists            = NewError(ErrConflict, "EMAIL_TAKEN", "email already registered")
+	ErrUsernameExists         = NewError(ErrConflict, "USERNAME_TAKEN", "username already taken")
+	ErrInvalidCredentials     = NewError(ErrUnauthorized, "INVALID_CREDENTIALS", "invalid email or password")
+	ErrUserNotFound           = NewError(ErrNotFound, "USER_NOT_FOUND", "user not found")
+	ErrInvalidToken           = NewError(ErrUnauthorized, "INVALID_TOKEN", "invalid or expired token")
+	ErrMissingAuth            = NewError(ErrUnauthorized, "MISSING_AUTH", "missing authorization header")
+	ErrAccountSuspended       = NewError(ErrForbidden, "ACCOUNT_SUSPENDED", "account suspended")
+	ErrInvalidRole            = NewError(ErrValidation, "INVALID_ROLE", "invalid role")
+	ErrVersionMismatch        = NewError(ErrPreconditionFailed, "VERSION_MISMATCH", "resource was modified by another request")
+	ErrWrongPassword          = NewError(ErrForbidden, "WRONG_PASSWORD", "current password is incorrect")
+	ErrEmailUnchanged         = NewError(ErrValidation, "EMAIL_UNCHANGED", "new email is the same as the current one")
+	ErrEmailChangeWait        = NewError(ErrRateLimited, "EMAIL_CHANGE_COOLDOWN", "email was changed or requested recently, try again later")
+	ErrEmailChangeToken       = NewError(ErrNotFound, "INVALID_EMAIL_CHANGE_TOKEN", "email change link is invalid or expired")
+	ErrPhoneRequired          = NewError(ErrValidation, "PHONE_REQUIRED", "add a phone number to the profile first")
+	ErrPhoneVerified          = NewError(ErrConflict, "PHONE_ALREADY_VERIFIED", "phone number is already verified")
+	ErrOTPCooldown            = NewError(ErrRateLimited, "OTP_COOLDOWN", "a code was sent recently, try again later")
+	ErrOTPNotFound            = NewError(ErrNotFound, "OTP_NOT_FOUND", "no valid code, request a new one")
+	ErrOTPInvalid             = NewError(ErrValidation, "INVALID_OTP", "code is incorrect")
+	ErrOTPAttempts            = NewError(ErrRateLimited, "OTP_ATTEMPTS_EXCEEDED", "too many wrong codes, request a new one")
+	ErrPendingDeletion        = NewError(ErrForbidden, "ACCOUNT_PENDING_DELETION", "account is scheduled for deletion, log in with restore set to true to restore it")
+	ErrSelfRelation           = NewError(ErrValidation, "SELF_RELATION", "you cannot add yourself as a contact or block yourself")
+	ErrExportInProgress       = NewError(ErrConflict, "EXPORT_IN_PROGRESS", "a data export is already being prepared")
+	ErrExportNotFound         = NewError(ErrNotFound, "EXPORT_NOT_FOUND", "data export not found")
+	ErrExportLink             = NewError(ErrNotFound, "INVALID_EXPORT_LINK", "download link is invalid or expired")
+	ErrInsufficientRole       = NewError(ErrForbidden, "INSUFFICIENT_ROLE", "you are not allowed to do this")
+	ErrUsernameCooldown       = NewError(ErrConflict, "USERNAME_COOLDOWN", "username was used by another account recently and cannot be taken yet")
+	ErrProfileVersionNotFound = NewError(ErrNotFound, "PROFILE_VERSION_NOT_FOUND", "no profile version at that time")
+	ErrUsernameGrantNotFound  = NewError(ErrNotFound, "USERNAME_GRANT_NOT_FOUND", "username grant not found")
+)
+
+// Stable machine-readable codes returned for errors without a specific code
+const (
+	CodeNotFound         = "NOT_FOUND"
+	CodeConflict         = "CONFLICT"
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodeInvalidJSON      = "INVALID_JSON"
+	CodeUnauthorized     = "UNAUTHORIZED"
+	CodeForbidden        = "FORBIDDEN"
+	CodePrecondition     = "PRECONDITION_FAILED"
+	CodeRateLimited      = "RATE_LIMITED"
+	CodeUnavailable      = "UNAVAILABLE"
+	CodeInternal         = "INTERNAL_ERROR"
 )
 
+// ErrorDetail describes why a single field was rejected
+type ErrorDetail struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error is an application error of a given kind with a stable code and an optional underlying cause
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Details []ErrorDetail
+	Err     error
+}
+
+// NewError creates an error of the given kind
+func NewError(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// WrapError creates an error of the given kind that keeps cause for errors.Is/As and logs
+func WrapError(kind error, code, message string, cause error) *Error {
+	return &Error{Kind: kind, Code: code, Message: message, Err: cause}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap exposes both the kind and the cause to errors.Is and errors.As
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
 // ValidationError represents a validation error with fields
 type ValidationError struct {
 	Field   string
+	Rule    string
+	Param   string
 	Message string
 }
 
 func (e *ValidationError) Error() string {
 	return e.Message
 }
+
+// Unwrap makes every ValidationError match ErrValidation
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// Detail returns the field-level detail of the error
+func (e *ValidationError) Detail() ErrorDetail {
+	rule := e.Rule
+	if rule == "" {
+		rule = "invalid"
+	}
+	return ErrorDetail{Field: e.Field, Rule: rule, Param: e.Param, Message: e.Message}
+}
+
+// ErrorCode returns the stable code of err, falling back to a code derived from its kind
+func ErrorCode(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) && appErr.Code != "" {
+		return appErr.Code
+	}
+	switch {
+	case errors.Is(err, ErrValidation):
+		return CodeValidationFailed
+	case errors.Is(err, ErrUnauthorized):
+		return CodeUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return CodeForbidden
+	case errors.Is(err, ErrNotFound):
+		return CodeNotFound
+	case errors.Is(err, ErrConflict):
+		return CodeConflict
+	case errors.Is(err, ErrPreconditionFailed):
+		return CodePrecondition
+	case errors.Is(err, ErrRateLimited):
+		return CodeRateLimited
+	case errors.Is(err, ErrUnavailable):
+		return CodeUnavailable
+	}
+	return CodeInternal
+}
+
+// ErrorDetails returns the field-level details carried by err, if any
+func ErrorDetails(err error) []ErrorDetail {
+	var appErr *Error
+	if errors.As(err, &appErr) && len(appErr.Details) > 0 {
+		return appErr.Details
+	}
+	var vErr *ValidationError
+	if errors.As(err, &vErr) {
+		return []ErrorDetail{vErr.Detail()}
+	}
+	return nil
+}
+
+// StatusCode maps an error to its HTTP status code
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
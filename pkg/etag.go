@@ -0,0 +1,38 @@
+package pkg
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidIfMatch is returned for an If-Match header that is not an ETag issued by SetETag
+var ErrInvalidIfMatch = NewError(ErrValidation, "INVALID_IF_MATCH", "If-Match header must be an ETag returned by this API")
+
+// SetETag sets the ETag response header for a resource at the given version
+func SetETag(c *gin.Context, version int64) {
+	c.Header("ETag", `"`+strconv.FormatInt(version, 10)+`"`)
+}
+
+// IfMatch returns the version required by the If-Match request header, or 0 when the header
+// is absent or "*" and the write is unconditional. A weak ETag fails with ErrVersionMismatch.
+func IfMatch(c *gin.Context) (int64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	// If-Match uses the strong comparison (RFC 7232 section 3.1), which a weak ETag never passes
+	if strings.HasPrefix(header, "W/") {
+		return 0, ErrVersionMismatch
+	}
+	tag := header
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, ErrInvalidIfMatch
+	}
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, ErrInvalidIfMatch
+	}
+	return version, nil
+}
@@ -0,0 +1,197 @@
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// version prefixes every ciphertext: v1.<kid>.<wrapped data key>.<sealed value>
+const version = "v1"
+
+// ErrNoKeyring is returned when values are encrypted or decrypted before SetKeyring
+var ErrNoKeyring = errors.New("fieldcrypt: keyring not configured")
+
+var (
+	mu      sync.RWMutex
+	keyring *Keyring
+)
+
+// SetKeyring sets the keyring used by Encrypt, Decrypt, BlindIndex and the GORM serializer
+func SetKeyring(kr *Keyring) {
+	mu.Lock()
+	defer mu.Unlock()
+	keyring = kr
+}
+
+// Configured reports whether a keyring is set
+func Configured() bool {
+	return current() != nil
+}
+
+func current() *Keyring {
+	mu.RLock()
+	defer mu.RUnlock()
+	return keyring
+}
+
+// Encrypt seals plaintext under a fresh data key wrapped by the active KEK. The domain (table and
+// column, e.g. "users.phone") is authenticated so a ciphertext cannot be moved to another column.
+func Encrypt(domain string, plaintext []byte) (string, error) {
+	kr := current()
+	if kr == nil {
+		return "", ErrNoKeyring
+	}
+	kek, ok := kr.Key(kr.Active)
+	if !ok {
+		return "", fmt.Errorf("fieldcrypt: active key %s not found", kr.Active)
+	}
+
+	dataKey, err := randomBytes(32)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := seal(kek, dataKey, []byte(kr.Active))
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dataKey, plaintext, []byte(domain))
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{version, kr.Active, encode(wrapped), encode(sealed)}, "."), nil
+}
+
+// Decrypt opens a value produced by Encrypt for the same domain
+func Decrypt(domain, value string) ([]byte, error) {
+	kr := current()
+	if kr == nil {
+		return nil, ErrNoKeyring
+	}
+	kid, wrapped, sealed, err := parse(value)
+	if err != nil {
+		return nil, err
+	}
+	kek, ok := kr.Key(kid)
+	if !ok {
+		return nil, fmt.Errorf("fieldcrypt: unknown key %s", kid)
+	}
+
+	dataKey, err := open(kek, wrapped, []byte(kid))
+	if err != nil {
+		return nil, fmt.Errorf("fieldcrypt: cannot unwrap data key: %w", err)
+	}
+	plaintext, err := open(dataKey, sealed, []byte(domain))
+	if err != nil {
+		return nil, fmt.Errorf("fieldcrypt: cannot decrypt %s: %w", domain, err)
+	}
+	return plaintext, nil
+}
+
+// KeyID returns the ID of the KEK a ciphertext was encrypted with
+func KeyID(value string) (string, error) {
+	kid, _, _, err := parse(value)
+	return kid, err
+}
+
+// NeedsReencryption reports whether value is wrapped by a key older than the active one. Values
+// of keys newer than the active one (written by an instance with a more recent keyring) are left alone.
+func NeedsReencryption(value string) bool {
+	kr := current()
+	if kr == nil {
+		return false
+	}
+	kid, err := KeyID(value)
+	return err == nil && kr.isRetired(kid)
+}
+
+// Reencrypt decrypts value and encrypts it again under the active KEK
+func Reencrypt(domain, value string) (string, error) {
+	plaintext, err := Decrypt(domain, value)
+	if err != nil {
+		return "", err
+	}
+	return Encrypt(domain, plaintext)
+}
+
+// BlindIndex returns a keyed hash of value for equality lookups on an encrypted column; it is
+// empty for empty values. Normalize value first: only identical inputs share an index.
+func BlindIndex(domain, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	kr := current()
+	if kr == nil {
+		return "", ErrNoKeyring
+	}
+	key, err := base64.StdEncoding.DecodeString(kr.IndexKey)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(domain))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return encode(mac.Sum(nil)), nil
+}
+
+func parse(value string) (kid string, wrapped, sealed []byte, err error) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 4 || parts[0] != version {
+		return "", nil, nil, errors.New("fieldcrypt: not an encrypted value")
+	}
+	if wrapped, err = decode(parts[2]); err != nil {
+		return "", nil, nil, err
+	}
+	if sealed, err = decode(parts[3]); err != nil {
+		return "", nil, nil, err
+	}
+	return parts[1], wrapped, sealed, nil
+}
+
+// seal encrypts with AES-256-GCM and returns nonce || ciphertext
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(key, data, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
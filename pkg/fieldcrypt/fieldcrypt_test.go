@@ -0,0 +1,197 @@
+package fieldcrypt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// useKeyring sets a fresh keyring for the test and unsets it afterwards
+func useKeyring(t *testing.T) *Keyring {
+	t.Helper()
+	kr, err := NewKeyring()
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	SetKeyring(kr)
+	t.Cleanup(func() { SetKeyring(nil) })
+	return kr
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	useKeyring(t)
+
+	for _, plaintext := range []string{"", "+6281234567890", strings.Repeat("x", 4096)} {
+		value, err := Encrypt("users.phone", []byte(plaintext))
+		if err != nil {
+			t.Fatalf("Encrypt(%.20q): %v", plaintext, err)
+		}
+		if strings.Contains(value, plaintext) && plaintext != "" {
+			t.Fatalf("ciphertext %q contains the plaintext", value)
+		}
+		got, err := Decrypt("users.phone", value)
+		if err != nil {
+			t.Fatalf("Decrypt(%.20q): %v", plaintext, err)
+		}
+		if string(got) != plaintext {
+			t.Fatalf("Decrypt = %.20q, want %.20q", got, plaintext)
+		}
+	}
+
+	a, _ := Encrypt("users.phone", []byte("same"))
+	b, _ := Encrypt("users.phone", []byte("same"))
+	if a == b {
+		t.Fatal("equal plaintexts encrypted to the same value")
+	}
+}
+
+func TestDecryptRejects(t *testing.T) {
+	kr := useKeyring(t)
+	value, err := Encrypt("users.phone", []byte("+6281234567890"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	parts := strings.Split(value, ".")
+	sealed, err := decode(parts[3])
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	sealed[len(sealed)-1] ^= 1
+
+	for _, tc := range []struct {
+		name   string
+		domain string
+		value  string
+	}{
+		{"WrongDomain", "profile_versions.phone", value},
+		{"UnknownKey", "users.phone", strings.Join([]string{parts[0], "0000000000000000", parts[2], parts[3]}, ".")},
+		{"TamperedValue", "users.phone", strings.Join([]string{parts[0], parts[1], parts[2], encode(sealed)}, ".")},
+		{"Plaintext", "users.phone", "+6281234567890"},
+		{"OtherVersion", "users.phone", "v0" + strings.TrimPrefix(value, "v1")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, err := Decrypt(tc.domain, tc.value); err == nil {
+				t.Fatalf("Decrypt = %q, want an error", got)
+			}
+		})
+	}
+
+	if kid, err := KeyID(value); err != nil || kid != kr.Active {
+		t.Fatalf("KeyID = %q, %v; want the active key %s", kid, err, kr.Active)
+	}
+}
+
+func TestNoKeyring(t *testing.T) {
+	SetKeyring(nil)
+	if Configured() {
+		t.Fatal("Configured with no keyring")
+	}
+	if _, err := Encrypt("users.phone", []byte("x")); !errors.Is(err, ErrNoKeyring) {
+		t.Fatalf("Encrypt = %v, want ErrNoKeyring", err)
+	}
+	if _, err := Decrypt("users.phone", "v1.a.b.c"); !errors.Is(err, ErrNoKeyring) {
+		t.Fatalf("Decrypt = %v, want ErrNoKeyring", err)
+	}
+	if _, err := BlindIndex("users.phone", "x"); !errors.Is(err, ErrNoKeyring) {
+		t.Fatalf("BlindIndex = %v, want ErrNoKeyring", err)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	kr := useKeyring(t)
+	old, err := Encrypt("users.phone", []byte("+6281234567890"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if NeedsReencryption(old) {
+		t.Fatal("a value of the active key needs re-encryption")
+	}
+
+	retired := kr.Active
+	if _, err := kr.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if got, err := Decrypt("users.phone", old); err != nil || string(got) != "+6281234567890" {
+		t.Fatalf("Decrypt with a retired key = %q, %v", got, err)
+	}
+	if !NeedsReencryption(old) {
+		t.Fatal("a value of a retired key does not need re-encryption")
+	}
+
+	fresh, err := Reencrypt("users.phone", old)
+	if err != nil {
+		t.Fatalf("Reencrypt: %v", err)
+	}
+	if kid, _ := KeyID(fresh); kid != kr.Active || NeedsReencryption(fresh) {
+		t.Fatalf("re-encrypted value has key %s, want the active %s", kid, kr.Active)
+	}
+	if got, err := Decrypt("users.phone", fresh); err != nil || string(got) != "+6281234567890" {
+		t.Fatalf("Decrypt(re-encrypted) = %q, %v", got, err)
+	}
+
+	if err := kr.Retire(kr.Active); err == nil {
+		t.Fatal("Retire removed the active key")
+	}
+	if err := kr.Retire(retired); err != nil {
+		t.Fatalf("Retire: %v", err)
+	}
+	if _, err := Decrypt("users.phone", old); err == nil {
+		t.Fatal("Decrypt succeeded after its key was retired")
+	}
+	if NeedsReencryption(old) {
+		t.Fatal("a value of a removed key needs re-encryption")
+	}
+}
+
+func TestNeedsReencryptionNewerKey(t *testing.T) {
+	kr := useKeyring(t)
+	older := kr.Active
+	if _, err := kr.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	newer, err := Encrypt("users.phone", []byte("x"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	// an instance that has not loaded the rotated keyring yet leaves the newer value alone
+	kr.Active = older
+	if NeedsReencryption(newer) {
+		t.Fatal("a value of a newer key needs re-encryption")
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	useKeyring(t)
+
+	index := func(domain, value string) string {
+		t.Helper()
+		got, err := BlindIndex(domain, value)
+		if err != nil {
+			t.Fatalf("BlindIndex(%s, %q): %v", domain, value, err)
+		}
+		return got
+	}
+
+	a := index("users.phone", "+6281234567890")
+	if a == "" || a != index("users.phone", "+6281234567890") {
+		t.Fatal("BlindIndex is not deterministic")
+	}
+	if a == index("users.phone", "+6281298765432") {
+		t.Fatal("different values share an index")
+	}
+	if a == index("profile_versions.phone", "+6281234567890") {
+		t.Fatal("different domains share an index")
+	}
+	if got := index("users.phone", ""); got != "" {
+		t.Fatalf("BlindIndex(empty) = %q, want empty", got)
+	}
+
+	// the index key is not rotated, so indexes survive a KEK rotation
+	if _, err := current().Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if index("users.phone", "+6281234567890") != a {
+		t.Fatal("rotation changed the index")
+	}
+}
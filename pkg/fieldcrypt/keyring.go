@@ -0,0 +1,148 @@
+// Package fieldcrypt encrypts individual database columns with envelope encryption: every value
+// gets its own AES-256-GCM data key, which is wrapped by a key-encryption key (KEK) from a local
+// keyring file. Ciphertexts carry the ID of their KEK, so keys can be rotated and values
+// re-encrypted in the background. Blind indexes allow equality lookups on encrypted columns.
+package fieldcrypt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Key is a key-encryption key, identified by the key ID stored in every ciphertext it wraps
+type Key struct {
+	ID        string    `json:"kid"`
+	Secret    string    `json:"secret"` // base64 encoded, 32 bytes
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Keyring holds the active KEK, the retired KEKs still needed to decrypt older values (newest
+// first) and the blind index key. The index key is never rotated: indexes are derived from it.
+type Keyring struct {
+	Active   string `json:"active"`
+	Keys     []Key  `json:"keys"`
+	IndexKey string `json:"index_key"` // base64 encoded, 32 bytes
+}
+
+// NewKeyring generates a keyring with a fresh active KEK and index key
+func NewKeyring() (*Keyring, error) {
+	index, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	kr := &Keyring{IndexKey: base64.StdEncoding.EncodeToString(index)}
+	if _, err := kr.Rotate(); err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// LoadKeyring reads a keyring from a JSON file
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var kr Keyring
+	if err := json.Unmarshal(data, &kr); err != nil {
+		return nil, err
+	}
+	if _, ok := kr.Key(kr.Active); !ok {
+		return nil, errors.New("keyring has no active key")
+	}
+	if index, err := base64.StdEncoding.DecodeString(kr.IndexKey); err != nil || len(index) != 32 {
+		return nil, errors.New("keyring has no valid index key")
+	}
+	return &kr, nil
+}
+
+// Save writes the keyring atomically with owner-only permissions
+func (kr *Keyring) Save(path string) error {
+	data, err := json.MarshalIndent(kr, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Key returns the secret of the KEK with the given ID
+func (kr *Keyring) Key(id string) ([]byte, bool) {
+	for _, k := range kr.Keys {
+		if k.ID == id {
+			secret, err := base64.StdEncoding.DecodeString(k.Secret)
+			return secret, err == nil && len(secret) == 32
+		}
+	}
+	return nil, false
+}
+
+// Rotate generates a new active KEK. Previous keys are kept: values they wrap stay readable
+// until the re-encryption job has moved them to the new key.
+func (kr *Keyring) Rotate() (Key, error) {
+	secret, err := randomBytes(32)
+	if err != nil {
+		return Key{}, err
+	}
+	id, err := randomBytes(8)
+	if err != nil {
+		return Key{}, err
+	}
+
+	key := Key{
+		ID:        hex.EncodeToString(id),
+		Secret:    base64.StdEncoding.EncodeToString(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+	kr.Keys = append([]Key{key}, kr.Keys...)
+	kr.Active = key.ID
+	return key, nil
+}
+
+// Retire removes a KEK that no value uses anymore; the active key cannot be retired
+func (kr *Keyring) Retire(id string) error {
+	if id == kr.Active {
+		return errors.New("cannot retire the active key")
+	}
+	for i, k := range kr.Keys {
+		if k.ID == id {
+			kr.Keys = append(kr.Keys[:i], kr.Keys[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("key not found")
+}
+
+// isRetired reports whether id names a known key older than the active one
+func (kr *Keyring) isRetired(id string) bool {
+	active := false
+	for _, k := range kr.Keys {
+		switch k.ID {
+		case kr.Active:
+			active = true
+		case id:
+			return active
+		}
+	}
+	return false
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,60 @@
+package fieldcrypt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyringSaveLoad(t *testing.T) {
+	kr, err := NewKeyring()
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	if _, err := kr.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "keys", "pii.json")
+	if err := kr.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Fatalf("keyring file mode %o, want 600", mode)
+	}
+
+	loaded, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatalf("LoadKeyring: %v", err)
+	}
+	if loaded.Active != kr.Active || len(loaded.Keys) != 2 || loaded.IndexKey != kr.IndexKey {
+		t.Fatalf("loaded %+v, want %+v", loaded, kr)
+	}
+	for _, k := range kr.Keys {
+		if _, ok := loaded.Key(k.ID); !ok {
+			t.Fatalf("loaded keyring lost key %s", k.ID)
+		}
+	}
+}
+
+func TestLoadKeyringRejects(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"NotJSON":     `keys`,
+		"NoActiveKey": `{"active": "missing", "keys": [], "index_key": "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}`,
+		"NoIndexKey":  `{"active": "a", "keys": [{"kid": "a", "secret": "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name+".json")
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if _, err := LoadKeyring(path); err == nil {
+				t.Fatal("LoadKeyring succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package fieldcrypt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is the GORM serializer of encrypted columns: `gorm:"serializer:encrypted"`
+const SerializerName = "encrypted"
+
+func init() {
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// Serializer stores a field JSON encoded and encrypted, in a TEXT column. Zero values are stored
+// as an empty string so "not set" stays queryable; the domain is "<table>.<column>".
+type Serializer struct{}
+
+// Scan implements schema.SerializerInterface
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	fieldValue := reflect.New(field.FieldType)
+
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("fieldcrypt: unexpected %T in encrypted column %s", dbValue, field.DBName)
+	}
+
+	if value != "" {
+		plaintext, err := Decrypt(Domain(field), value)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(plaintext, fieldValue.Interface()); err != nil {
+			return err
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).Set(fieldValue.Elem())
+	return nil
+}
+
+// Value implements schema.SerializerValuerInterface
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	return EncryptValue(Domain(field), fieldValue)
+}
+
+// EncryptValue encrypts the JSON encoding of v the way the serializer stores it
+func EncryptValue(domain string, v interface{}) (string, error) {
+	if rv := reflect.ValueOf(v); !rv.IsValid() || rv.IsZero() {
+		return "", nil
+	}
+	plaintext, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return Encrypt(domain, plaintext)
+}
+
+// Domain returns the domain of an encrypted field, "<table>.<column>"
+func Domain(field *schema.Field) string {
+	return field.Schema.Table + "." + field.DBName
+}
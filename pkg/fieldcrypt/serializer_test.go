@@ -0,0 +1,119 @@
+package fieldcrypt
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type secretRow struct {
+	ID       uint
+	Phone    string     `gorm:"serializer:encrypted"`
+	Birthday *time.Time `gorm:"serializer:encrypted"`
+}
+
+func openSecrets(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.Exec("CREATE TABLE secret_rows (id INTEGER PRIMARY KEY, phone TEXT, birthday TEXT)").Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// stored returns the raw phone and birthday columns of a row
+func stored(t *testing.T, db *gorm.DB, id uint) (phone, birthday *string) {
+	t.Helper()
+	row := db.Raw("SELECT phone, birthday FROM secret_rows WHERE id = ?", id).Row()
+	if err := row.Scan(&phone, &birthday); err != nil {
+		t.Fatalf("read raw row: %v", err)
+	}
+	return phone, birthday
+}
+
+func TestSerializer(t *testing.T) {
+	useKeyring(t)
+	db := openSecrets(t)
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		birthday := time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)
+		row := secretRow{Phone: "+6281234567890", Birthday: &birthday}
+		if err := db.Create(&row).Error; err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		phone, rawBirthday := stored(t, db, row.ID)
+		if _, err := KeyID(*phone); err != nil {
+			t.Fatalf("stored phone %q is not encrypted", *phone)
+		}
+		if _, err := Decrypt("secret_rows.phone", *phone); err != nil {
+			t.Fatalf("stored phone does not decrypt for its column: %v", err)
+		}
+		if _, err := Decrypt("secret_rows.birthday", *phone); err == nil {
+			t.Fatal("stored phone decrypts for another column")
+		}
+		if _, err := KeyID(*rawBirthday); err != nil {
+			t.Fatalf("stored birthday %q is not encrypted", *rawBirthday)
+		}
+
+		var got secretRow
+		if err := db.First(&got, row.ID).Error; err != nil {
+			t.Fatalf("First: %v", err)
+		}
+		if got.Phone != row.Phone || got.Birthday == nil || !got.Birthday.Equal(birthday) {
+			t.Fatalf("read %+v, want %+v", got, row)
+		}
+	})
+
+	t.Run("ZeroValuesStoredEmpty", func(t *testing.T) {
+		row := secretRow{}
+		if err := db.Create(&row).Error; err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if phone, birthday := stored(t, db, row.ID); *phone != "" || *birthday != "" {
+			t.Fatalf("stored %q, %q; want empty strings", *phone, *birthday)
+		}
+
+		var got secretRow
+		if err := db.First(&got, row.ID).Error; err != nil {
+			t.Fatalf("First: %v", err)
+		}
+		if got.Phone != "" || got.Birthday != nil {
+			t.Fatalf("read %+v, want zero values", got)
+		}
+	})
+
+	t.Run("NullReadsAsZero", func(t *testing.T) {
+		if err := db.Exec("INSERT INTO secret_rows (id, phone, birthday) VALUES (100, NULL, NULL)").Error; err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+		got := secretRow{Phone: "stale"}
+		if err := db.First(&got, 100).Error; err != nil {
+			t.Fatalf("First: %v", err)
+		}
+		if got.Phone != "" || got.Birthday != nil {
+			t.Fatalf("read %+v, want zero values", got)
+		}
+	})
+
+	t.Run("PlaintextFails", func(t *testing.T) {
+		if err := db.Exec("INSERT INTO secret_rows (id, phone) VALUES (101, '+6281234567890')").Error; err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+		var got secretRow
+		if err := db.First(&got, 101).Error; err == nil {
+			t.Fatalf("read plaintext column as %+v, want an error", got)
+		}
+	})
+}
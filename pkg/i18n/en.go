@@ -0,0 +1,100 @@
+package i18n
+
+var en = map[string]string{
+	// Error codes
+	"EMAIL_TAKEN":                "email already registered",
+	"USERNAME_TAKEN":             "username already taken",
+	"INVALID_CREDENTIALS":        "invalid email or password",
+	"USER_NOT_FOUND":             "user not found",
+	"INVALID_TOKEN":              "invalid or expired token",
+	"MISSING_AUTH":               "missing authorization header",
+	"ACCOUNT_SUSPENDED":          "account suspended",
+	"INVALID_ROLE":               "invalid role",
+	"NOT_FOUND":                  "resource not found",
+	"CONFLICT":                   "resource already exists",
+	"PRECONDITION_FAILED":        "precondition failed",
+	"VERSION_MISMATCH":           "resource was modified by another request, reload it and try again",
+	"INVALID_IF_MATCH":           "If-Match header must be an ETag returned by this API",
+	"WRONG_PASSWORD":             "current password is incorrect",
+	"EMAIL_UNCHANGED":            "new email is the same as the current one",
+	"EMAIL_CHANGE_COOLDOWN":      "email was changed or requested recently, try again later",
+	"INVALID_EMAIL_CHANGE_TOKEN": "email change link is invalid or expired",
+	"PHONE_REQUIRED":             "add a phone number to the profile first",
+	"PHONE_ALREADY_VERIFIED":     "phone number is already verified",
+	"OTP_COOLDOWN":               "a code was sent recently, try again later",
+	"OTP_NOT_FOUND":              "no valid code, request a new one",
+	"INVALID_OTP":                "code is incorrect",
+	"OTP_ATTEMPTS_EXCEEDED":      "too many wrong codes, request a new one",
+	"SELF_RELATION":              "you cannot add yourself as a contact or block yourself",
+	"ACCOUNT_PENDING_DELETION":   "account is scheduled for deletion, log in with restore set to true to restore it",
+	"EXPORT_IN_PROGRESS":         "a data export is already being prepared",
+	"EXPORT_NOT_FOUND":           "data export not found",
+	"INVALID_EXPORT_LINK":        "download link is invalid or expired",
+	"INSUFFICIENT_ROLE":          "you are not allowed to do this",
+	"USERNAME_COOLDOWN":          "username was used by another account recently and cannot be taken yet",
+	"PROFILE_VERSION_NOT_FOUND":  "no profile version at that time",
+	"USERNAME_GRANT_NOT_FOUND":   "username grant not found",
+	"VALIDATION_FAILED":          "request validation failed",
+	"INVALID_JSON":               "request body is not valid JSON",
+	"UNAUTHORIZED":               "unauthorized",
+	"FORBIDDEN":                  "forbidden",
+	"RATE_LIMITED":               "too many requests, please try again later",
+	"UNAVAILABLE":                "service temporarily unavailable",
+	"DATABASE_UNAVAILABLE":       "database unavailable",
+	"SMS_UNAVAILABLE":            "text message could not be sent, try again later",
+	"INTERNAL_ERROR":             "internal server error",
+
+	// Success messages
+	"USER_REGISTERED":         "user registered successfully",
+	"LOGIN_SUCCESSFUL":        "login successful",
+	"PROFILE_FETCHED":         "profile fetched successfully",
+	"PROFILE_UPDATED":         "profile updated successfully",
+	"PROFILE_DELETED":         "account scheduled for deletion, log in before purge_at to restore it",
+	"AVATAR_UPDATED":          "avatar updated successfully",
+	"EMAIL_CHANGE_REQUESTED":  "check your new email address to confirm the change",
+	"EMAIL_CHANGED":           "email changed, please log in again",
+	"EMAIL_CHANGE_CANCELLED":  "email change cancelled",
+	"PHONE_OTP_SENT":          "verification code sent",
+	"PHONE_VERIFIED":          "phone number verified",
+	"VISIBILITY_FETCHED":      "profile visibility fetched successfully",
+	"VISIBILITY_UPDATED":      "profile visibility updated successfully",
+	"CONTACTS_FETCHED":        "contacts fetched successfully",
+	"BLOCKS_FETCHED":          "blocked users fetched successfully",
+	"CONTACT_ADDED":           "contact added",
+	"CONTACT_REMOVED":         "contact removed",
+	"USER_BLOCKED":            "user blocked",
+	"USER_UNBLOCKED":          "user unblocked",
+	"SETTINGS_FETCHED":        "settings fetched successfully",
+	"SETTINGS_UPDATED":        "settings updated successfully",
+	"EXPORT_REQUESTED":        "data export started, a download link will be emailed when it is ready",
+	"EXPORT_FETCHED":          "data export fetched successfully",
+	"AUDIT_FETCHED":           "audit log fetched successfully",
+	"PROFILE_HISTORY_FETCHED": "profile history fetched successfully",
+	"USERNAME_GRANTS_FETCHED": "username grants fetched successfully",
+	"USERNAME_GRANTED":        "username granted successfully",
+	"USERNAME_GRANT_REVOKED":  "username grant revoked successfully",
+
+	// Field validation rules
+	"validation.required":  "is required",
+	"validation.email":     "must be a valid email address",
+	"validation.min":       "must be at least {param} characters",
+	"validation.max":       "must be at most {param} characters",
+	"validation.len":       "must be exactly {param} characters",
+	"validation.oneof":     "must be one of: {param}",
+	"validation.datetime":  "must match the format {param}",
+	"validation.type":      "must be a {param}",
+	"validation.date":      "must be a valid date in YYYY-MM-DD format",
+	"validation.max_size":  "file is too large (max {param})",
+	"validation.file_type": "file type must be one of: {param}",
+	"validation.invalid":   "is invalid",
+	"validation.unknown":   "is not a field that can be changed",
+	"validation.phone":     "must be a valid phone number",
+	"validation.timezone":  "must be an IANA time zone such as Asia/Jakarta",
+	"validation.range":     "must be between {param}",
+
+	"validation.username_chars":  "may only contain letters, digits, dots, underscores and hyphens",
+	"validation.username_format": "must contain a letter, start and end with a letter or digit and not have two separators in a row",
+	"validation.reserved":        "is reserved",
+	"validation.not_reserved":    "is not a reserved username",
+	"validation.profanity":       "contains a word that is not allowed",
+}
@@ -0,0 +1,156 @@
+// Package i18n holds the message catalogs of the API and negotiates the locale of a request.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported locales
+const (
+	English    = "en"
+	Indonesian = "id"
+
+	// Default is used when no supported locale is requested and for missing translations
+	Default = English
+)
+
+// Success message keys passed to pkg.JSONSuccess
+const (
+	MsgUserRegistered  = "USER_REGISTERED"
+	MsgLoginSuccessful = "LOGIN_SUCCESSFUL"
+	MsgProfileFetched  = "PROFILE_FETCHED"
+	MsgProfileUpdated  = "PROFILE_UPDATED"
+	MsgProfileDeleted  = "PROFILE_DELETED"
+	MsgAvatarUpdated   = "AVATAR_UPDATED"
+
+	MsgEmailChangeRequested = "EMAIL_CHANGE_REQUESTED"
+	MsgEmailChanged         = "EMAIL_CHANGED"
+	MsgEmailChangeCancelled = "EMAIL_CHANGE_CANCELLED"
+
+	MsgPhoneOTPSent  = "PHONE_OTP_SENT"
+	MsgPhoneVerified = "PHONE_VERIFIED"
+
+	MsgVisibilityFetched = "VISIBILITY_FETCHED"
+	MsgVisibilityUpdated = "VISIBILITY_UPDATED"
+	MsgContactsFetched   = "CONTACTS_FETCHED"
+	MsgBlocksFetched     = "BLOCKS_FETCHED"
+	MsgContactAdded      = "CONTACT_ADDED"
+	MsgContactRemoved    = "CONTACT_REMOVED"
+	MsgUserBlocked       = "USER_BLOCKED"
+	MsgUserUnblocked     = "USER_UNBLOCKED"
+
+	MsgSettingsFetched = "SETTINGS_FETCHED"
+	MsgSettingsUpdated = "SETTINGS_UPDATED"
+
+	MsgExportRequested = "EXPORT_REQUESTED"
+	MsgExportFetched   = "EXPORT_FETCHED"
+
+	MsgAuditFetched          = "AUDIT_FETCHED"
+	MsgProfileHistoryFetched = "PROFILE_HISTORY_FETCHED"
+
+	MsgUsernameGrantsFetched = "USERNAME_GRANTS_FETCHED"
+	MsgUsernameGranted       = "USERNAME_GRANTED"
+	MsgUsernameGrantRevoked  = "USERNAME_GRANT_REVOKED"
+)
+
+// catalogs maps locale -> message key -> message; keys are error codes,
+// success message keys and "validation.<rule>" keys. Messages may contain {param}.
+var catalogs = map[string]map[string]string{
+	English:    en,
+	Indonesian: id,
+}
+
+// Supported returns the supported locales
+func Supported() []string {
+	locales := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// IsSupported reports whether locale has a catalog
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Has reports whether key exists in the default catalog
+func Has(key string) bool {
+	_, ok := catalogs[Default][key]
+	return ok
+}
+
+// T returns the message for key in locale, falling back to English and then to the key itself
+func T(locale, key string, param string) string {
+	msg, ok := catalogs[locale][key]
+	if !ok {
+		msg, ok = catalogs[Default][key]
+	}
+	if !ok {
+		return key
+	}
+	return strings.ReplaceAll(msg, "{param}", param)
+}
+
+// Negotiate picks the best supported locale from an Accept-Language header
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		locale string
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(f), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		base, _, _ := strings.Cut(tag, "-")
+		if base == "in" { // legacy code for Indonesian
+			base = Indonesian
+		}
+		if IsSupported(base) && q > 0 {
+			candidates = append(candidates, candidate{locale: base, q: q})
+		}
+	}
+	if len(candidates) == 0 {
+		return Default
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].locale
+}
+
+// Validate returns an error listing every key of the default catalog missing from another catalog
+func Validate() error {
+	var missing []string
+	for locale, catalog := range catalogs {
+		for key := range catalogs[Default] {
+			if _, ok := catalog[key]; !ok {
+				missing = append(missing, locale+":"+key)
+			}
+		}
+		for key := range catalog {
+			if _, ok := catalogs[Default][key]; !ok {
+				missing = append(missing, Default+":"+key)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("message catalogs are incomplete: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	if err := Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+}
+
+func TestValidateMissingKey(t *testing.T) {
+	const key = "USER_NOT_FOUND"
+	message, ok := catalogs[Indonesian][key]
+	if !ok {
+		t.Fatalf("catalog %s has no %s", Indonesian, key)
+	}
+	delete(catalogs[Indonesian], key)
+	t.Cleanup(func() { catalogs[Indonesian][key] = message })
+
+	err := Validate()
+	if err == nil {
+		t.Fatal("Validate succeeded with a missing key")
+	}
+	if want := Indonesian + ":" + key; !strings.Contains(err.Error(), want) {
+		t.Fatalf("Validate = %q, want it to name %s", err, want)
+	}
+}
+
+func TestValidateExtraKey(t *testing.T) {
+	const key = "ONLY_IN_INDONESIAN"
+	catalogs[Indonesian][key] = "hanya dalam bahasa Indonesia"
+	t.Cleanup(func() { delete(catalogs[Indonesian], key) })
+
+	err := Validate()
+	if err == nil {
+		t.Fatal("Validate succeeded with a key missing from the default catalog")
+	}
+	if want := Default + ":" + key; !strings.Contains(err.Error(), want) {
+		t.Fatalf("Validate = %q, want it to name %s", err, want)
+	}
+}
@@ -0,0 +1,100 @@
+package i18n
+
+var id = map[string]string{
+	// Kode error
+	"EMAIL_TAKEN":                "email sudah terdaftar",
+	"USERNAME_TAKEN":             "username sudah digunakan",
+	"INVALID_CREDENTIALS":        "email atau kata sandi salah",
+	"USER_NOT_FOUND":             "pengguna tidak ditemukan",
+	"INVALID_TOKEN":              "token tidak valid atau kedaluwarsa",
+	"MISSING_AUTH":               "header otorisasi tidak ada",
+	"ACCOUNT_SUSPENDED":          "akun ditangguhkan",
+	"INVALID_ROLE":               "peran tidak valid",
+	"NOT_FOUND":                  "data tidak ditemukan",
+	"CONFLICT":                   "data sudah ada",
+	"PRECONDITION_FAILED":        "prasyarat tidak terpenuhi",
+	"VERSION_MISMATCH":           "data telah diubah oleh permintaan lain, muat ulang lalu coba lagi",
+	"INVALID_IF_MATCH":           "header If-Match harus berupa ETag yang diberikan oleh API ini",
+	"WRONG_PASSWORD":             "kata sandi saat ini salah",
+	"EMAIL_UNCHANGED":            "email baru sama dengan email saat ini",
+	"EMAIL_CHANGE_COOLDOWN":      "email baru saja diubah atau diminta, silakan coba lagi nanti",
+	"INVALID_EMAIL_CHANGE_TOKEN": "tautan perubahan email tidak valid atau kedaluwarsa",
+	"PHONE_REQUIRED":             "tambahkan nomor telepon ke profil terlebih dahulu",
+	"PHONE_ALREADY_VERIFIED":     "nomor telepon sudah terverifikasi",
+	"OTP_COOLDOWN":               "kode baru saja dikirim, silakan coba lagi nanti",
+	"OTP_NOT_FOUND":              "tidak ada kode yang berlaku, minta kode baru",
+	"INVALID_OTP":                "kode salah",
+	"OTP_ATTEMPTS_EXCEEDED":      "terlalu banyak kode salah, minta kode baru",
+	"SELF_RELATION":              "Anda tidak dapat menambahkan atau memblokir diri sendiri",
+	"ACCOUNT_PENDING_DELETION":   "akun dijadwalkan untuk dihapus, masuk dengan restore bernilai true untuk memulihkannya",
+	"EXPORT_IN_PROGRESS":         "ekspor data sedang disiapkan",
+	"EXPORT_NOT_FOUND":           "ekspor data tidak ditemukan",
+	"INVALID_EXPORT_LINK":        "tautan unduhan tidak valid atau sudah kedaluwarsa",
+	"INSUFFICIENT_ROLE":          "anda tidak diizinkan melakukan ini",
+	"USERNAME_COOLDOWN":          "nama pengguna baru saja dipakai akun lain dan belum bisa diambil",
+	"PROFILE_VERSION_NOT_FOUND":  "tidak ada versi profil pada waktu itu",
+	"USERNAME_GRANT_NOT_FOUND":   "izin nama pengguna tidak ditemukan",
+	"VALIDATION_FAILED":          "validasi permintaan gagal",
+	"INVALID_JSON":               "isi permintaan bukan JSON yang valid",
+	"UNAUTHORIZED":               "tidak terautentikasi",
+	"FORBIDDEN":                  "akses ditolak",
+	"RATE_LIMITED":               "terlalu banyak permintaan, silakan coba lagi nanti",
+	"UNAVAILABLE":                "layanan sedang tidak tersedia",
+	"DATABASE_UNAVAILABLE":       "database tidak tersedia",
+	"SMS_UNAVAILABLE":            "SMS tidak dapat dikirim, silakan coba lagi nanti",
+	"INTERNAL_ERROR":             "terjadi kesalahan pada server",
+
+	// Pesan sukses
+	"USER_REGISTERED":         "pengguna berhasil didaftarkan",
+	"LOGIN_SUCCESSFUL":        "berhasil masuk",
+	"PROFILE_FETCHED":         "profil berhasil diambil",
+	"PROFILE_UPDATED":         "profil berhasil diperbarui",
+	"PROFILE_DELETED":         "akun dijadwalkan untuk dihapus, masuk sebelum purge_at untuk memulihkannya",
+	"AVATAR_UPDATED":          "avatar berhasil diperbarui",
+	"EMAIL_CHANGE_REQUESTED":  "periksa alamat email baru Anda untuk mengonfirmasi perubahan",
+	"EMAIL_CHANGED":           "email berhasil diubah, silakan masuk kembali",
+	"EMAIL_CHANGE_CANCELLED":  "perubahan email dibatalkan",
+	"PHONE_OTP_SENT":          "kode verifikasi telah dikirim",
+	"PHONE_VERIFIED":          "nomor telepon berhasil diverifikasi",
+	"VISIBILITY_FETCHED":      "visibilitas profil berhasil diambil",
+	"VISIBILITY_UPDATED":      "visibilitas profil berhasil diperbarui",
+	"CONTACTS_FETCHED":        "kontak berhasil diambil",
+	"BLOCKS_FETCHED":          "pengguna yang diblokir berhasil diambil",
+	"CONTACT_ADDED":           "kontak ditambahkan",
+	"CONTACT_REMOVED":         "kontak dihapus",
+	"USER_BLOCKED":            "pengguna diblokir",
+	"USER_UNBLOCKED":          "blokir pengguna dibuka",
+	"SETTINGS_FETCHED":        "pengaturan berhasil diambil",
+	"SETTINGS_UPDATED":        "pengaturan berhasil diperbarui",
+	"EXPORT_REQUESTED":        "ekspor data dimulai, tautan unduhan akan dikirim melalui email setelah siap",
+	"EXPORT_FETCHED":          "ekspor data berhasil diambil",
+	"AUDIT_FETCHED":           "log audit berhasil diambil",
+	"PROFILE_HISTORY_FETCHED": "riwayat profil berhasil diambil",
+	"USERNAME_GRANTS_FETCHED": "izin nama pengguna berhasil diambil",
+	"USERNAME_GRANTED":        "nama pengguna berhasil diizinkan",
+	"USERNAME_GRANT_REVOKED":  "izin nama pengguna berhasil dicabut",
+
+	// Aturan validasi field
+	"validation.required":  "wajib diisi",
+	"validation.email":     "harus berupa alamat email yang valid",
+	"validation.min":       "minimal {param} karakter",
+	"validation.max":       "maksimal {param} karakter",
+	"validation.len":       "harus tepat {param} karakter",
+	"validation.oneof":     "harus salah satu dari: {param}",
+	"validation.datetime":  "harus sesuai format {param}",
+	"validation.type":      "harus bertipe {param}",
+	"validation.date":      "harus berupa tanggal yang valid dengan format YYYY-MM-DD",
+	"validation.max_size":  "ukuran file terlalu besar (maks {param})",
+	"validation.file_type": "tipe file harus salah satu dari: {param}",
+	"validation.invalid":   "tidak valid",
+	"validation.unknown":   "bukan kolom yang dapat diubah",
+	"validation.phone":     "harus berupa nomor telepon yang valid",
+	"validation.timezone":  "harus berupa zona waktu IANA seperti Asia/Jakarta",
+	"validation.range":     "harus di antara {param}",
+
+	"validation.username_chars":  "hanya boleh berisi huruf, angka, titik, garis bawah dan tanda hubung",
+	"validation.username_format": "harus berisi huruf, diawali dan diakhiri huruf atau angka, dan tidak boleh ada dua pemisah berturut-turut",
+	"validation.reserved":        "sudah dicadangkan",
+	"validation.not_reserved":    "bukan nama pengguna yang dicadangkan",
+	"validation.profanity":       "mengandung kata yang tidak diperbolehkan",
+}
@@ -0,0 +1,71 @@
+// Package identity normalizes the email addresses and usernames that identify an account.
+package identity
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/vayura/pkg"
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrInvalidEmail is returned by NormalizeEmail for addresses that cannot be normalized
+var ErrInvalidEmail = &pkg.ValidationError{Field: "email", Rule: "email", Message: "must be a valid email address"}
+
+// NormalizeEmail trims and lowercases email and converts its domain to the ASCII (punycode) form,
+// so every spelling of the same mailbox maps to one stored value
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return "", ErrInvalidEmail
+	}
+
+	domain, err := idna.Lookup.ToASCII(email[at+1:])
+	if err != nil {
+		return "", ErrInvalidEmail
+	}
+	local := strings.ToLower(norm.NFKC.String(email[:at]))
+	return local + "@" + strings.ToLower(domain), nil
+}
+
+// NormalizeUsername trims username and applies Unicode NFKC; letter case is kept for display
+func NormalizeUsername(username string) string {
+	return norm.NFKC.String(strings.TrimSpace(username))
+}
+
+// Skeleton returns the form of username used to detect look-alikes: NFKC, case folded,
+// without diacritics and with confusable characters mapped to a single Latin representative.
+// Two usernames with the same skeleton are considered the same identity.
+func Skeleton(username string) string {
+	s := strings.ToLower(NormalizeUsername(username))
+
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if mapped, ok := confusables[r]; ok {
+			r = mapped
+		}
+		b.WriteRune(r)
+	}
+	return confusableSequences.Replace(b.String())
+}
+
+// confusables maps characters that render like a Latin letter or digit to that letter
+var confusables = map[rune]rune{
+	// digits and punctuation
+	'0': 'o', '1': 'l', '|': 'l', 'ı': 'i', 'ȷ': 'j',
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'һ': 'h', 'і': 'i', 'ї': 'i', 'ј': 'j', 'к': 'k',
+	'ӏ': 'l', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'ԛ': 'q', 'ѕ': 's', 'т': 't', 'с': 'c',
+	'у': 'y', 'ԝ': 'w', 'х': 'x', 'ԁ': 'd', 'ɡ': 'g', 'ʋ': 'v', 'ѵ': 'v', 'ү': 'y',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+}
+
+// confusableSequences replaces letter pairs that render like a single letter
+var confusableSequences = strings.NewReplacer("rn", "m", "vv", "w")
@@ -0,0 +1,143 @@
+package identity
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/vayura/pkg"
+)
+
+// ErrUsernameReserved is the validation error of a reserved username taken without a grant
+var ErrUsernameReserved = &pkg.ValidationError{Field: "username", Rule: "reserved", Message: "username is reserved"}
+
+// DefaultReservedUsernames are reserved by every UsernameRules: the path segments of the API and
+// web app, and names that suggest an official account
+var DefaultReservedUsernames = []string{
+	// route segments
+	"api", "auth", "login", "register", "email-change", "confirm", "cancel", "user", "users",
+	"profile", "avatar", "visibility", "settings", "contacts", "blocks", "phone", "verification",
+	"export", "exports", "download", "admin", "audit", "history", "username-grants", "health",
+	"livez", "readyz",
+	// official accounts
+	"root", "administrator", "sysadmin", "system", "support", "help", "security", "staff",
+	"moderator", "official", "vayura", "team", "info", "contact", "noreply", "postmaster",
+	"abuse", "webmaster", "hostmaster", "null", "undefined", "anonymous",
+}
+
+// DefaultBlockedWords are profanities no username may contain, in English and Indonesian
+var DefaultBlockedWords = []string{
+	"fuck", "shit", "cunt", "bitch", "whore", "slut", "nigger", "nigga", "faggot", "wanker",
+	"asshole", "dickhead", "cocksucker", "bastard",
+	"kontol", "memek", "ngentot", "bangsat", "jancok", "pepek", "pelacur", "bajingan",
+}
+
+// UsernameRules are the format, reserved word and profanity rules of usernames. Reserved words
+// and profanities are compared by their folded form, so separators, letter case, look-alike
+// characters and leetspeak ("4dm1n", "f.u.c.k") do not get around them.
+type UsernameRules struct {
+	minLength, maxLength int
+	reserved             map[string]bool
+	blocked              []string
+}
+
+// NewUsernameRules returns rules for usernames of minLength to maxLength characters; reserved
+// and blocked are added to DefaultReservedUsernames and DefaultBlockedWords
+func NewUsernameRules(minLength, maxLength int, reserved, blocked []string) *UsernameRules {
+	r := &UsernameRules{minLength: minLength, maxLength: maxLength, reserved: map[string]bool{}}
+	for _, word := range append(append([]string{}, DefaultReservedUsernames...), reserved...) {
+		if folded := fold(word); folded != "" {
+			r.reserved[folded] = true
+		}
+	}
+	for _, word := range append(append([]string{}, DefaultBlockedWords...), blocked...) {
+		if folded := fold(word); folded != "" {
+			r.blocked = append(r.blocked, folded)
+		}
+	}
+	return r
+}
+
+// Validate checks the length, characters and format of username and that it contains no
+// profanity. Usernames are letters and digits of any script, with single dots, underscores or
+// hyphens between them, and at least one letter, so they never read as a user ID.
+func (r *UsernameRules) Validate(username string) error {
+	username = NormalizeUsername(username)
+	length := utf8.RuneCountInString(username)
+	if length < r.minLength {
+		param := strconv.Itoa(r.minLength)
+		return &pkg.ValidationError{Field: "username", Rule: "min", Param: param, Message: "username must be at least " + param + " characters"}
+	}
+	if length > r.maxLength {
+		param := strconv.Itoa(r.maxLength)
+		return &pkg.ValidationError{Field: "username", Rule: "max", Param: param, Message: "username must be at most " + param + " characters"}
+	}
+
+	var (
+		letters  int
+		previous rune
+	)
+	for i, c := range username {
+		switch {
+		case unicode.IsLetter(c):
+			letters++
+		case unicode.IsDigit(c):
+		case isUsernameSeparator(c):
+			if i == 0 || i+utf8.RuneLen(c) == len(username) || isUsernameSeparator(previous) {
+				return errUsernameFormat
+			}
+		default:
+			return &pkg.ValidationError{Field: "username", Rule: "username_chars", Message: "username may only contain letters, digits, dots, underscores and hyphens"}
+		}
+		previous = c
+	}
+	if letters == 0 {
+		return errUsernameFormat
+	}
+
+	folded := fold(username)
+	for _, word := range r.blocked {
+		if strings.Contains(folded, word) {
+			return &pkg.ValidationError{Field: "username", Rule: "profanity", Message: "username contains a word that is not allowed"}
+		}
+	}
+	return nil
+}
+
+// Reserved reports whether username is a reserved word or a look-alike of one
+func (r *UsernameRules) Reserved(username string) bool {
+	return r.reserved[fold(username)]
+}
+
+// errUsernameFormat is returned for misplaced separators and usernames without a letter
+var errUsernameFormat = &pkg.ValidationError{Field: "username", Rule: "username_format", Message: "username must contain a letter, start and end with a letter or digit and not have two separators in a row"}
+
+func isUsernameSeparator(c rune) bool {
+	return c == '.' || c == '_' || c == '-'
+}
+
+// fold returns the form of s that reserved words and profanities are matched against: the
+// skeleton of s with leetspeak digits read as letters and separators removed. Skeleton reads '1'
+// as 'l' while leetspeak also uses it for 'i', so both letters fold to 'l' and either reading of
+// a '1', '!' or '|' matches.
+func fold(s string) string {
+	s = strings.ToLower(NormalizeUsername(s))
+	var b strings.Builder
+	for _, c := range s {
+		if isUsernameSeparator(c) {
+			continue
+		}
+		if letter, ok := leetspeak[c]; ok {
+			c = letter
+		}
+		b.WriteRune(c)
+	}
+	return strings.ReplaceAll(Skeleton(b.String()), "i", "l")
+}
+
+// leetspeak maps digits and symbols written for letters to those letters; Skeleton already reads
+// '0', '1' and '|', and '!' goes to 'l' like them
+var leetspeak = map[rune]rune{
+	'3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '9': 'g', '@': 'a', '$': 's', '!': 'l',
+}
@@ -1,6 +1,8 @@
 package pkg
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"errors"
 	"time"
 
@@ -9,6 +11,7 @@ import (
 
 var jwtSecret string
 var jwtExpiresIn time.Duration
+var jwtKeys *KeySet
 
 // SetJWTSecret sets the JWT secret key
 func SetJWTSecret(secret string) {
@@ -20,22 +23,41 @@ func SetJWTExpiration(d time.Duration) {
 	jwtExpiresIn = d
 }
 
-// GenerateJWT generates a JWT token for the user
-func GenerateJWT(userID uint, email string) (string, error) {
-	if jwtSecret == "" {
-		return "", errors.New("JWT secret not configured")
-	}
+// SetJWTKeys enables kid-based signing; tokens without kid are still verified with the JWT secret
+func SetJWTKeys(ks *KeySet) {
+	jwtKeys = ks
+}
 
+// GenerateJWT generates a JWT token for the user; sessionID, if set, becomes the jti claim
+func GenerateJWT(userID uint, email, sessionID string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"email":   email,
-		"exp":     time.Now().Add(getExpiration()).Unix(),
+		"iat":     time.Now().Unix(),
+		"exp":     time.Now().Add(JWTExpiration()).Unix(),
+	}
+	if sessionID != "" {
+		claims["jti"] = sessionID
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	if jwtKeys != nil {
+		secret, ok := jwtKeys.Key(jwtKeys.Active)
+		if !ok {
+			return "", errors.New("active JWT key not found")
+		}
+		token.Header["kid"] = jwtKeys.Active
+		return token.SignedString(secret)
+	}
+
+	if jwtSecret == "" {
+		return "", errors.New("JWT secret not configured")
+	}
 	return token.SignedString([]byte(jwtSecret))
 }
 
-func getExpiration() time.Duration {
+// JWTExpiration returns how long issued tokens are valid
+func JWTExpiration() time.Duration {
 	if jwtExpiresIn <= 0 {
 		return 72 * time.Hour
 	}
@@ -44,14 +66,20 @@ func getExpiration() time.Duration {
 
 // VerifyJWT verifies and parses a JWT token
 func VerifyJWT(tokenString string) (*jwt.Token, error) {
-	if jwtSecret == "" {
-		return nil, errors.New("JWT secret not configured")
-	}
-
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
+		if kid, ok := token.Header["kid"].(string); ok && jwtKeys != nil {
+			secret, found := jwtKeys.Key(kid)
+			if !found {
+				return nil, errors.New("unknown signing key")
+			}
+			return secret, nil
+		}
+		if jwtSecret == "" {
+			return nil, errors.New("JWT secret not configured")
+		}
 		return []byte(jwtSecret), nil
 	})
 
@@ -66,3 +94,55 @@ func ExtractClaims(token *jwt.Token) (jwt.MapClaims, error) {
 	}
 	return claims, nil
 }
+
+// ErrUnknownSigningKey is returned for signatures made with a key no longer in the key set
+var ErrUnknownSigningKey = errors.New("unknown signing key")
+
+// Sign signs payload with HMAC-SHA256 under the active JWT key, or JWT_SECRET without a key set,
+// in which case kid is empty
+func Sign(payload []byte) (kid string, signature []byte, err error) {
+	if jwtKeys != nil {
+		kid = jwtKeys.Active
+	}
+	secret, err := signingSecret(kid)
+	if err != nil {
+		return "", nil, err
+	}
+	return kid, signHMAC(secret, payload), nil
+}
+
+// VerifySignature checks a signature made by Sign with the key kid
+func VerifySignature(kid string, payload, signature []byte) error {
+	secret, err := signingSecret(kid)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(signHMAC(secret, payload), signature) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// signingSecret returns the secret of the key kid, or JWT_SECRET for an empty kid
+func signingSecret(kid string) ([]byte, error) {
+	if kid == "" {
+		if jwtSecret == "" {
+			return nil, errors.New("JWT secret not configured")
+		}
+		return []byte(jwtSecret), nil
+	}
+	if jwtKeys == nil {
+		return nil, ErrUnknownSigningKey
+	}
+	secret, ok := jwtKeys.Key(kid)
+	if !ok {
+		return nil, ErrUnknownSigningKey
+	}
+	return secret, nil
+}
+
+func signHMAC(secret, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
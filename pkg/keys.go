@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SigningKey is an HMAC key used to sign JWTs, identified by its kid header
+type SigningKey struct {
+	ID        string    `json:"kid"`
+	Secret    string    `json:"secret"` // base64 encoded
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// KeySet holds the active signing key and the previous keys still accepted for verification
+type KeySet struct {
+	Active string       `json:"active"`
+	Keys   []SigningKey `json:"keys"`
+}
+
+// LoadKeySet reads a key set from a JSON file
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ks KeySet
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, err
+	}
+	if _, ok := ks.Key(ks.Active); !ok {
+		return nil, errors.New("key set has no active key")
+	}
+	return &ks, nil
+}
+
+// Save writes the key set atomically with owner-only permissions
+func (ks *KeySet) Save(path string) error {
+	data, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Key returns the secret of the key with the given ID
+func (ks *KeySet) Key(id string) ([]byte, bool) {
+	for _, k := range ks.Keys {
+		if k.ID == id {
+			secret, err := base64.StdEncoding.DecodeString(k.Secret)
+			return secret, err == nil
+		}
+	}
+	return nil, false
+}
+
+// Rotate generates a new active key and keeps at most retain previous keys
+func (ks *KeySet) Rotate(retain int) (SigningKey, error) {
+	secret := make([]byte, 32)
+	id := make([]byte, 8)
+	if _, err := rand.Read(secret); err != nil {
+		return SigningKey{}, err
+	}
+	if _, err := rand.Read(id); err != nil {
+		return SigningKey{}, err
+	}
+
+	key := SigningKey{
+		ID:        hex.EncodeToString(id),
+		Secret:    base64.StdEncoding.EncodeToString(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+	ks.Keys = append([]SigningKey{key}, ks.Keys...)
+	if retain >= 0 && len(ks.Keys) > retain+1 {
+		ks.Keys = ks.Keys[:retain+1]
+	}
+	ks.Active = key.ID
+	return key, nil
+}
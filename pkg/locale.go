@@ -0,0 +1,63 @@
+package pkg
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/vayura/pkg/i18n"
+)
+
+// localePreference resolves the stored language of the authenticated user, if any
+var localePreference func(c *gin.Context) string
+
+// SetLocalePreference registers the resolver of a user's preferred locale;
+// it takes precedence over Accept-Language for authenticated requests
+func SetLocalePreference(fn func(c *gin.Context) string) {
+	localePreference = fn
+}
+
+// GetLocale returns the locale of the request: a `lang` query override, the user
+// preference, then Accept-Language, falling back to English
+func GetLocale(c *gin.Context) string {
+	if locale := c.GetString("locale"); locale != "" {
+		return locale
+	}
+
+	locale := ""
+	if q := c.Query("lang"); i18n.IsSupported(q) {
+		locale = q
+	}
+	if locale == "" && localePreference != nil {
+		if pref := localePreference(c); i18n.IsSupported(pref) {
+			locale = pref
+		}
+	}
+	if locale == "" {
+		locale = i18n.Negotiate(c.GetHeader("Accept-Language"))
+	}
+
+	c.Set("locale", locale)
+	return locale
+}
+
+// translateMessage localizes a success message key; unknown keys are returned unchanged
+func translateMessage(c *gin.Context, key string) string {
+	if !i18n.Has(key) {
+		return key
+	}
+	return i18n.T(GetLocale(c), key, "")
+}
+
+// translateDetails localizes field details by their rule
+func translateDetails(c *gin.Context, details []ErrorDetail) []ErrorDetail {
+	if len(details) == 0 {
+		return nil
+	}
+	locale := GetLocale(c)
+	out := make([]ErrorDetail, len(details))
+	for i, d := range details {
+		out[i] = d
+		if key := "validation." + d.Rule; i18n.Has(key) {
+			out[i].Message = i18n.T(locale, key, d.Param)
+		}
+	}
+	return out
+}
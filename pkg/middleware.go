@@ -1,50 +1,181 @@
 package pkg
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware validates JWT token and sets user context
-func AuthMiddleware() gin.HandlerFunc {
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID reuses a well-formed incoming X-Request-ID or generates one, and exposes it
+// on the gin context, the request context and the response header
+func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			JSONUnauthorized(c, ErrMissingAuth)
-			c.Abort()
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			b := make([]byte, 12)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+
+		c.Set("requestID", id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID returns the request ID of the current request
+func GetRequestID(c *gin.Context) string {
+	return c.GetString("requestID")
+}
+
+// RequestIDFromContext returns the request ID stored by the RequestID middleware
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ErrorHandler renders the last error added with c.Error using the status mapped by StatusCode.
+// Errors without a known kind become a 500 whose details are only logged.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
 			return
 		}
+		err := c.Errors.Last().Err
+		status := StatusCode(err)
+		if status == http.StatusInternalServerError {
+			log.Printf("❌ [%s] %s %s: %v", GetRequestID(c), c.Request.Method, c.Request.URL.Path, err)
+			err = errors.New("internal server error")
+		}
+		JSONError(c, status, err)
+	}
+}
+
+// SessionValidator rejects tokens whose session (jti, empty for tokens without one) is no longer active
+type SessionValidator func(ctx context.Context, userID uint, sessionID string) error
+
+var sessionValidator SessionValidator
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			JSONUnauthorized(c, ErrInvalidToken)
+// SetSessionValidator makes AuthMiddleware check every token's session
+func SetSessionValidator(v SessionValidator) {
+	sessionValidator = v
+}
+
+// RoleLookup returns the current role of a user
+type RoleLookup func(ctx context.Context, userID uint) (string, error)
+
+var roleLookup RoleLookup
+
+// SetRoleLookup sets how RequireRole reads the role of the authenticated user
+func SetRoleLookup(l RoleLookup) {
+	roleLookup = l
+}
+
+// RequireRole rejects requests whose authenticated user does not have role; it runs after
+// AuthMiddleware. The role is read on every request, so a demoted user loses access at once.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := GetUserID(c)
+		if !ok || roleLookup == nil {
+			c.Error(ErrInsufficientRole)
 			c.Abort()
 			return
 		}
-
-		token, err := VerifyJWT(tokenString)
-		if err != nil || !token.Valid {
-			JSONUnauthorized(c, ErrInvalidToken)
+		current, err := roleLookup(c.Request.Context(), userID)
+		if err == nil && current != role {
+			err = ErrInsufficientRole
+		}
+		if err != nil {
+			c.Error(err)
 			c.Abort()
 			return
 		}
+		c.Next()
+	}
+}
 
-		claims, err := ExtractClaims(token)
-		if err != nil {
-			JSONUnauthorized(c, ErrInvalidToken)
+// AuthMiddleware validates JWT token and sets user context
+func AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Error(ErrMissingAuth)
+			c.Abort()
+			return
+		}
+		if err := authenticate(c); err != nil {
+			c.Error(err)
 			c.Abort()
 			return
 		}
+		c.Next()
+	}
+}
 
-		userIDFloat, _ := claims["user_id"].(float64)
-		email, _ := claims["email"].(string)
+// OptionalAuthMiddleware sets user context when a token is sent and lets anonymous requests through;
+// an invalid token is still rejected
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			if err := authenticate(c); err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
 
-		c.Set("userID", uint(userIDFloat))
-		c.Set("email", email)
+// authenticate validates the bearer token of the request and sets user context
+func authenticate(c *gin.Context) error {
+	authHeader := c.GetHeader("Authorization")
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == authHeader {
+		return ErrInvalidToken
+	}
 
-		c.Next()
+	token, err := VerifyJWT(tokenString)
+	if err != nil || !token.Valid {
+		return ErrInvalidToken
+	}
+
+	claims, err := ExtractClaims(token)
+	if err != nil {
+		return ErrInvalidToken
 	}
+
+	userIDFloat, _ := claims["user_id"].(float64)
+	email, _ := claims["email"].(string)
+	sessionID, _ := claims["jti"].(string)
+
+	if sessionValidator != nil {
+		if err := sessionValidator(c.Request.Context(), uint(userIDFloat), sessionID); err != nil {
+			return err
+		}
+	}
+
+	c.Set("userID", uint(userIDFloat))
+	c.Set("email", email)
+	c.Set("sessionID", sessionID)
+	c.Request = c.Request.WithContext(WithActorUser(c.Request.Context(), uint(userIDFloat)))
+	return nil
 }
 
 // GetUserID extracts user ID from context
@@ -66,3 +197,8 @@ func GetEmail(c *gin.Context) (string, bool) {
 	emailStr, ok := email.(string)
 	return emailStr, ok
 }
+
+// GetSessionID returns the session of the authenticated request; empty for tokens without one
+func GetSessionID(c *gin.Context) string {
+	return c.GetString("sessionID")
+}
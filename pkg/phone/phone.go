@@ -0,0 +1,117 @@
+// Package phone normalizes phone numbers to E.164 (+<country code><subscriber number>).
+package phone
+
+import (
+	"strings"
+
+	"github.com/vayura/pkg"
+)
+
+// ErrInvalidPhone is returned by Normalize for numbers that are not valid in E.164
+var ErrInvalidPhone = &pkg.ValidationError{Field: "phone", Rule: "phone", Message: "must be a valid phone number"}
+
+// DefaultRegion is the region of numbers written without a country code unless SetDefaultRegion changes it
+const DefaultRegion = "ID"
+
+var defaultRegion = DefaultRegion
+
+// SetDefaultRegion sets the region used by Normalize; unsupported regions are ignored
+func SetDefaultRegion(r string) {
+	if IsSupportedRegion(r) {
+		defaultRegion = strings.ToUpper(r)
+	}
+}
+
+// region describes how national numbers of a country are written
+type region struct {
+	code      string // country calling code
+	trunk     string // national trunk prefix dropped before the country code is added
+	minDigits int    // length of the national significant number
+	maxDigits int
+}
+
+// regions are the supported defaults for numbers written in national format
+var regions = map[string]region{
+	"ID": {code: "62", trunk: "0", minDigits: 8, maxDigits: 12},
+	"MY": {code: "60", trunk: "0", minDigits: 8, maxDigits: 10},
+	"SG": {code: "65", minDigits: 8, maxDigits: 8},
+	"PH": {code: "63", trunk: "0", minDigits: 8, maxDigits: 10},
+	"TH": {code: "66", trunk: "0", minDigits: 8, maxDigits: 9},
+	"AU": {code: "61", trunk: "0", minDigits: 9, maxDigits: 9},
+	"GB": {code: "44", trunk: "0", minDigits: 9, maxDigits: 10},
+	"US": {code: "1", trunk: "1", minDigits: 10, maxDigits: 10},
+}
+
+// IsSupportedRegion reports whether numbers of region can be written without a country code
+func IsSupportedRegion(r string) bool {
+	_, ok := regions[strings.ToUpper(r)]
+	return ok
+}
+
+// Normalize returns number in E.164, reading national numbers as numbers of the default region
+func Normalize(number string) (string, error) {
+	return NormalizeIn(number, defaultRegion)
+}
+
+// NormalizeIn returns number in E.164. Spaces, dashes, dots and parentheses are ignored; numbers
+// starting with + or 00 are international, others are national numbers of region.
+func NormalizeIn(number, region string) (string, error) {
+	digits, international, ok := clean(number)
+	if !ok {
+		return "", ErrInvalidPhone
+	}
+
+	if !international {
+		reg, ok := regions[strings.ToUpper(region)]
+		if !ok {
+			reg = regions[DefaultRegion]
+		}
+		// Also accept the country code written without the plus
+		if reg.trunk != "" && strings.HasPrefix(digits, reg.trunk) {
+			digits = strings.TrimPrefix(digits, reg.trunk)
+		} else if strings.HasPrefix(digits, reg.code) && len(digits)-len(reg.code) >= reg.minDigits {
+			digits = strings.TrimPrefix(digits, reg.code)
+		}
+		if len(digits) < reg.minDigits || len(digits) > reg.maxDigits {
+			return "", ErrInvalidPhone
+		}
+		digits = reg.code + digits
+	}
+
+	// E.164 allows at most 15 digits and country codes never start with 0
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	for _, reg := range regions {
+		if strings.HasPrefix(digits, reg.code) {
+			if n := len(digits) - len(reg.code); n < reg.minDigits || n > reg.maxDigits {
+				return "", ErrInvalidPhone
+			}
+			break
+		}
+	}
+	return "+" + digits, nil
+}
+
+// clean strips formatting from number and reports whether it was written in international format
+func clean(number string) (digits string, international bool, ok bool) {
+	number = strings.TrimSpace(number)
+	switch {
+	case strings.HasPrefix(number, "+"):
+		number, international = number[1:], true
+	case strings.HasPrefix(number, "00"):
+		number, international = number[2:], true
+	}
+
+	var b strings.Builder
+	for _, r := range number {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", false, false
+		}
+	}
+	return b.String(), international, b.Len() > 0
+}
@@ -4,29 +4,59 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/vayura/pkg/i18n"
 )
 
 // APIResponse represents the standard response structure
 type APIResponse struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success   bool          `json:"success"`
+	Message   string        `json:"message,omitempty"`
+	Data      interface{}   `json:"data,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Code      string        `json:"code,omitempty"`
+	Details   []ErrorDetail `json:"details,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+}
+
+// statusCodes gives untyped errors a code matching the status they are reported with
+var statusCodes = map[int]string{
+	http.StatusBadRequest:          CodeValidationFailed,
+	http.StatusUnauthorized:        CodeUnauthorized,
+	http.StatusForbidden:           CodeForbidden,
+	http.StatusNotFound:            CodeNotFound,
+	http.StatusConflict:            CodeConflict,
+	http.StatusPreconditionFailed:  CodePrecondition,
+	http.StatusTooManyRequests:     CodeRateLimited,
+	http.StatusServiceUnavailable:  CodeUnavailable,
+	http.StatusInternalServerError: CodeInternal,
 }
 
 // JSON response helper functions
 func JSONSuccess(c *gin.Context, statusCode int, message string, data interface{}) {
 	c.JSON(statusCode, APIResponse{
-		Success: true,
-		Message: message,
-		Data:    data,
+		Success:   true,
+		Message:   translateMessage(c, message),
+		Data:      data,
+		RequestID: GetRequestID(c),
 	})
 }
 
+// JSONError writes err with its stable code and field details, localized to the request locale.
+// Typed errors are translated by code; untyped errors keep their own message.
 func JSONError(c *gin.Context, statusCode int, err error) {
+	message := err.Error()
+	code := ErrorCode(err)
+	if mapped, ok := statusCodes[statusCode]; ok && code == CodeInternal {
+		code = mapped
+	} else if i18n.Has(code) {
+		message = i18n.T(GetLocale(c), code, "")
+	}
 	c.JSON(statusCode, APIResponse{
-		Success: false,
-		Error:   err.Error(),
+		Success:   false,
+		Error:     message,
+		Code:      code,
+		Details:   translateDetails(c, ErrorDetails(err)),
+		RequestID: GetRequestID(c),
 	})
 }
 
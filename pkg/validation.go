@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/vayura/pkg/i18n"
+)
+
+// SetupValidator makes gin's validator report fields by their JSON names
+func SetupValidator() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+}
+
+// BindError translates a request binding/decoding error into a validation error with field details
+func BindError(err error) error {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		details := make([]ErrorDetail, 0, len(verrs))
+		for _, fe := range verrs {
+			param := fe.Param()
+			if fe.Tag() == "oneof" {
+				param = strings.Join(strings.Fields(param), ", ")
+			}
+			details = append(details, ErrorDetail{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Param:   param,
+				Message: ruleMessage(fe.Tag(), param),
+			})
+		}
+		return &Error{Kind: ErrValidation, Code: CodeValidationFailed, Message: "request validation failed", Details: details, Err: err}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &Error{
+			Kind:    ErrValidation,
+			Code:    CodeValidationFailed,
+			Message: "request validation failed",
+			Details: []ErrorDetail{{Field: typeErr.Field, Rule: "type", Param: typeErr.Type.String(), Message: ruleMessage("type", typeErr.Type.String())}},
+			Err:     err,
+		}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return WrapError(ErrValidation, CodeInvalidJSON, "request body is not valid JSON", err)
+	}
+
+	return WrapError(ErrValidation, CodeValidationFailed, err.Error(), err)
+}
+
+// ruleMessage returns the default (English) message for a failed validator rule
+func ruleMessage(rule, param string) string {
+	key := "validation." + rule
+	if !i18n.Has(key) {
+		return "failed the " + rule + " rule"
+	}
+	return i18n.T(i18n.Default, key, param)
+}
@@ -3,15 +3,16 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/vayura/internal/handler"
+	"github.com/vayura/internal/models"
 	"github.com/vayura/pkg"
 )
 
 // SetupRoutes configures all API routes with dependency injection
-func SetupRoutes(router *gin.Engine, authHandler *handler.AuthHandler, userHandler *handler.UserHandler) {
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+func SetupRoutes(router *gin.Engine, authHandler *handler.AuthHandler, userHandler *handler.UserHandler, emailChangeHandler *handler.EmailChangeHandler, phoneHandler *handler.PhoneHandler, profileHandler *handler.ProfileHandler, settingsHandler *handler.SettingsHandler, exportHandler *handler.ExportHandler, auditHandler *handler.AuditHandler, historyHandler *handler.ProfileHistoryHandler, grantHandler *handler.UsernameGrantHandler, healthHandler *handler.HealthHandler) {
+	// Health probes; /health is kept as an alias of /readyz
+	router.GET("/livez", healthHandler.Livez)
+	router.GET("/readyz", healthHandler.Readyz)
+	router.GET("/health", healthHandler.Readyz)
 
 	// API routes
 	api := router.Group("/api")
@@ -19,6 +20,14 @@ func SetupRoutes(router *gin.Engine, authHandler *handler.AuthHandler, userHandl
 		// Public auth routes
 		api.POST("/auth/register", authHandler.Register)
 		api.POST("/auth/login", authHandler.Login)
+		api.POST("/auth/email-change/confirm", emailChangeHandler.Confirm)
+		api.POST("/auth/email-change/cancel", emailChangeHandler.Cancel)
+
+		// Data export downloads; the emailed token authorizes them
+		api.GET("/exports/download", exportHandler.Download)
+
+		// Public profiles; a token is optional and decides which fields are visible
+		api.GET("/users/:username", pkg.OptionalAuthMiddleware(), profileHandler.PublicProfile)
 
 		// Protected routes
 		protected := api.Group("/")
@@ -27,8 +36,41 @@ func SetupRoutes(router *gin.Engine, authHandler *handler.AuthHandler, userHandl
 			// User profile CRUD
 			protected.GET("/user/profile", userHandler.GetProfile)
 			protected.PUT("/user/profile", userHandler.UpdateProfile)
+			protected.PATCH("/user/profile", userHandler.PatchProfile)
 			protected.DELETE("/user/profile", userHandler.DeleteProfile)
 			protected.POST("/user/avatar", userHandler.UploadAvatar)
+			protected.POST("/user/email-change", emailChangeHandler.Request)
+			protected.POST("/user/phone/verification", phoneHandler.SendCode)
+			protected.POST("/user/phone/verification/confirm", phoneHandler.Verify)
+
+			// Settings and preferences
+			protected.GET("/user/settings", settingsHandler.GetSettings)
+			protected.PATCH("/user/settings", settingsHandler.UpdateSettings)
+
+			// Personal data export
+			protected.POST("/user/export", exportHandler.Request)
+			protected.GET("/user/export", exportHandler.Latest)
+
+			// Profile visibility, contacts and blocks
+			protected.GET("/user/visibility", profileHandler.GetVisibility)
+			protected.PATCH("/user/visibility", profileHandler.UpdateVisibility)
+			protected.GET("/user/contacts", profileHandler.ListContacts)
+			protected.PUT("/user/contacts/:username", profileHandler.AddContact)
+			protected.DELETE("/user/contacts/:username", profileHandler.RemoveContact)
+			protected.GET("/user/blocks", profileHandler.ListBlocks)
+			protected.PUT("/user/blocks/:username", profileHandler.Block)
+			protected.DELETE("/user/blocks/:username", profileHandler.Unblock)
+		}
+
+		// Admin routes; the role is checked against the database on every request
+		admin := api.Group("/admin")
+		admin.Use(pkg.AuthMiddleware(), pkg.RequireRole(models.RoleAdmin))
+		{
+			admin.GET("/audit", auditHandler.List)
+			admin.GET("/users/:id/history", historyHandler.List)
+			admin.GET("/username-grants", grantHandler.List)
+			admin.PUT("/username-grants/:username", grantHandler.Grant)
+			admin.DELETE("/username-grants/:username", grantHandler.Revoke)
 		}
 	}
 }